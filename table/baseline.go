@@ -0,0 +1,77 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    baseline.go
+ *  Description: Observed behaviour compared between runs.
+ */
+
+import (
+	"strings"
+)
+
+// An element which observes the behaviour of the code it tests, whether or
+// not it asserts anything about it. The value returned by Observe is
+// serialized, as snapshots are (see Snapshot), into the element's Result. With
+// the Baseline option, observations are compared to those of an earlier run.
+// An ElementObserve may have Before, After and Panics methods, like an
+// ElementBeforeAfter or an ElementPanics. An element implementing both Element
+// and ElementObserve is tested with Observe.
+type ElementObserve interface {
+	Observe(T) interface{} // Execute the test, returning what was observed.
+}
+
+// An ElementObserve as an Element.
+type observeElem struct {
+	hooks
+	elem ElementObserve
+}
+
+func (e observeElem) Test(t T) {
+	v := e.elem.Observe(t)
+	if tt, ok := t.(*testingT); ok {
+		tt.observe(serialize(v))
+	}
+}
+
+// Compare the observations of the table's elements (see ElementObserve) to
+// the baseline recorded in the file at path, failing elements whose behaviour
+// changed with a diff. Elements missing from the baseline are logged. When
+// tests are run with the -table.update flag the baseline is recorded instead,
+// and entries for elements which no longer exist are removed. The file has
+// the format of a snapshot file, and like one is shared by the tables of a Go
+// test, so it is finished with the test. Different Go tests must not share a
+// baseline file.
+func Baseline(path string) Option { return func(c *config) { c.baselineFile = path } }
+
+func (c *config) baselines() *snapshots {
+	if c == nil {
+		return nil
+	}
+	return c.baseline
+}
+
+// Record the serialized observation got and compare it to the baseline.
+func (t *testingT) observe(got string) {
+	if t.state != nil {
+		t.state.Lock()
+		t.state.observed = got
+		t.state.Unlock()
+	}
+	b := t.cfg.baselines()
+	if b == nil {
+		return
+	}
+	key, want, ok, err := b.take(strings.TrimPrefix(t.name, t.cfg.prefix), got)
+	switch {
+	case err != nil:
+		t.Error(err)
+	case *update:
+	case !ok:
+		t.Logf("no baseline %q in %s (run with -table.update to record it)", key, b.path)
+	case got != want:
+		t.Errorf("behaviour changed since baseline %q (run with -table.update to accept it)\n%s", key, lineDiff(got, want))
+	}
+}
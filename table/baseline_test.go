@@ -0,0 +1,111 @@
+package table
+
+/*  Filename:    baseline_test.go
+ *  Description: For testing baseline.go
+ */
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Observes its value, logging msg.
+type observer struct {
+	v   interface{}
+	msg string
+}
+
+func (test observer) Observe(t T) interface{} {
+	if test.msg != "" {
+		t.Log(test.msg)
+	}
+	return test.v
+}
+
+// Observes a panic.
+type panicObserver []PanicExpectation
+
+func (test panicObserver) Observe(t T) interface{}    { panic("boom") }
+func (test panicObserver) Panics() []PanicExpectation { return test }
+
+var panicObserverTests = []metaTestSimple{
+	{"expected", testWrapped(panicObserver{"boom"}), nil},
+	{"mismatch", testWrapped(panicObserver{"bang"}), []string{`^simple meta-test: .*bang`}},
+	{"unexpected", testWrapped(panicObserver(nil)), []string{`^simple meta-test: unexpected panic: boom\n`}},
+}
+
+func TestObservePanics(t *testing.T) {
+	for i, test := range panicObserverTests {
+		elementTest(subT(sprintf("observe panics %d", i), t), test)
+	}
+}
+
+func baselineRun(path string, updating bool, table map[string]observer, opts ...Option) *fauxT {
+	defer func(u bool) { *update = u }(*update)
+	*update = updating
+	opts = append(opts, Baseline(path))
+	return fauxTest("base", func(t T) { testWith(t, table, opts...) })
+}
+
+func TestBaseline(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "x.base")
+	ft := baselineRun(path, false, map[string]observer{"a": {1, ""}})
+	if ft.failed || !ft.logLike(`^base: a: no baseline "a" in .*x.base \(run with -table.update to record it\)$`) {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+
+	table := map[string]observer{"a": {1, ""}, "b": {[]int{3}, ""}}
+	if ft := baselineRun(path, true, table); ft.failed || len(ft.log) > 0 {
+		t.Fatalf("update failed: %v", ft.log)
+	}
+	if p, _ := os.ReadFile(path); string(p) != "--- a\n1\n\n--- b\n[]int{\n\t3,\n}\n" {
+		t.Errorf("baseline file %q", p)
+	}
+	if ft := baselineRun(path, false, table); ft.failed || len(ft.log) > 0 {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+
+	ft = baselineRun(path, false, map[string]observer{"b": {[]int{3, 4}, ""}})
+	wantLog := []string{
+		"base: b: behaviour changed since baseline \"b\" (run with -table.update to accept it)\n  ...\n  \t3,\n+ \t4,\n  }",
+		`base: obsolete baseline entries in ` + path + ` (run with -table.update to remove them): ["a"]`,
+	}
+	if sprint(ft.log) != sprint(wantLog) || !ft.failed {
+		t.Errorf("unexpected log %q", ft.log)
+	}
+}
+
+func TestObserved(t *testing.T) {
+	rep := new(recordReporter)
+	path := filepath.Join(t.TempDir(), "x.base")
+	baselineRun(path, false, map[string]observer{"a": {"x", "saw x"}}, Report(rep), fakeClock(0))
+	want := []Result{{
+		Name:     "base: a",
+		Observed: `"x"`,
+		Log:      []string{"base: a: saw x", `base: a: no baseline "a" in ` + path + " (run with -table.update to record it)"},
+	}}
+	if !reflect.DeepEqual(rep.results, want) {
+		t.Errorf("unexpected results %#v", rep.results)
+	}
+	if _, err := os.Stat(path); err == nil {
+		t.Errorf("baseline written without -table.update")
+	}
+}
+
+func TestBaselineShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "x.base")
+	defer func(u bool) { *update = u }(*update)
+	*update = true
+	ct := &cleanupT{fauxT: new(fauxT), name: "TestX"}
+	testWith(ct, map[string]observer{"a": {1, ""}}, Baseline(path))
+	testWith(ct, map[string]observer{"b": {2, ""}}, Baseline(path))
+	if len(ct.cleanup) != 1 {
+		t.Fatalf("%d cleanup functions", len(ct.cleanup))
+	}
+	ct.cleanup[0]()
+	if p, _ := os.ReadFile(path); string(p) != "--- a\n1\n\n--- b\n2\n" || ct.failed {
+		t.Errorf("baseline file %q (log %v)", p, ct.log)
+	}
+}
@@ -0,0 +1,288 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    call.go
+ *  Description: Tables of function calls and their results.
+ */
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// A table of calls to a function, created by Func.
+type FuncTable struct {
+	fn   reflect.Value
+	rows reflect.Value
+	name string // Name of the function, for naming rows.
+	loc  string // Where Func was called.
+	locs *locations
+}
+
+// A table calling fn with the inputs of each row and comparing its results to
+// the row's expected results. Rows must be a slice of structs with the fields
+//
+//	In   // The argument of fn, or a slice or struct of its arguments.
+//	Want // The result of fn, or a slice or struct of its results.
+//
+// and optionally
+//
+//	Name string           // Names the row; defaults to the call, e.g. `Atoi("12")`.
+//	Err  ErrorExpectation // The error expected when fn's last result is an error.
+//
+// Results are compared with reflect.DeepEqual. An error result is checked
+// against Err (or nil when a row has no Err field) and, when non-nil, the
+// row's other results are ignored. A variadic fn takes its variadic arguments
+// in a slice. Structs of arguments or results must have only exported fields.
+// Numeric inputs and wants are converted to the types of fn's parameters and
+// results. For example,
+//
+//	table.Test(t, table.Func(strconv.Atoi, []struct {
+//		In   string
+//		Want int
+//		Err  table.ErrorExpectation
+//	}{
+//		{"12", 12, nil},
+//		{"x", 0, strconv.ErrSyntax},
+//	}))
+//
+// Func panics if fn is not a function or rows lacks the In or Want fields fn
+// requires.
+func Func(fn, rows interface{}) *FuncTable {
+	f := &FuncTable{fn: reflect.ValueOf(fn), rows: reflect.ValueOf(rows)}
+	if f.fn.Kind() != reflect.Func || f.fn.IsNil() {
+		panic(sprintf("table: Func: %T is not a function", fn))
+	}
+	if f.rows.Kind() != reflect.Slice || f.rows.Type().Elem().Kind() != reflect.Struct {
+		panic(sprintf("table: Func: rows %T are not a slice of structs", rows))
+	}
+	typ, rowTyp := f.fn.Type(), f.rows.Type().Elem()
+	if _, ok := rowTyp.FieldByName("In"); !ok && typ.NumIn() > 0 {
+		panic(sprintf("table: Func: rows %v have no In field", rowTyp))
+	}
+	if _, ok := rowTyp.FieldByName("Want"); !ok && len(resultTypes(typ)) > 0 {
+		panic(sprintf("table: Func: rows %v have no Want field", rowTyp))
+	}
+	f.name = "func"
+	if fun := runtime.FuncForPC(f.fn.Pointer()); fun != nil {
+		f.name = fun.Name()[strings.LastIndex(fun.Name(), "/")+1:]
+	}
+	if _, file, line, ok := runtime.Caller(1); ok {
+		f.loc = fileLine(file, line)
+		f.locs = findLocations(file, line, 1, "Func")
+	}
+	return f
+}
+
+// The results of functions of type typ, other than a trailing error.
+func resultTypes(typ reflect.Type) (types []reflect.Type) {
+	for i := 0; i < typ.NumOut(); i++ {
+		types = append(types, typ.Out(i))
+	}
+	if n := len(types); n > 0 && types[n-1] == errorType {
+		types = types[:n-1]
+	}
+	return
+}
+
+// The rows of the table as elements.
+func (f *FuncTable) Table() interface{} {
+	var rows []interface{}
+	for i := 0; i < f.rows.Len(); i++ {
+		c := callElem{f, f.rows.Index(i)}
+		name := c.field("Name")
+		if name.Kind() != reflect.String || name.String() == "" {
+			name = reflect.ValueOf(c.String())
+		}
+		loc := f.locs.atIndex(i)
+		if loc == "" {
+			loc = f.loc
+		}
+		rows = append(rows, row{c, loc, name.String()})
+	}
+	return rows
+}
+
+// A row of a FuncTable.
+type callElem struct {
+	f   *FuncTable
+	row reflect.Value
+}
+
+// A field of the row, or the zero Value if it has none.
+func (c callElem) field(name string) reflect.Value {
+	return c.row.FieldByName(name)
+}
+
+// Split a field holding n values (the value itself when n is 1) into values.
+func split(v reflect.Value, n int) ([]reflect.Value, error) {
+	if n == 1 {
+		return []reflect.Value{v}, nil
+	}
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	var vals []reflect.Value
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			vals = append(vals, v.Index(i))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); !f.IsExported() {
+				return nil, errorf("%v has unexported field %s", v.Type(), f.Name)
+			}
+			vals = append(vals, v.Field(i))
+		}
+	default:
+		return nil, errorf("%v is not a slice or struct of %d values", v.Type(), n)
+	}
+	if len(vals) != n {
+		return nil, errorf("%d values; need %d", len(vals), n)
+	}
+	return vals, nil
+}
+
+func isNumeric(k reflect.Kind) bool {
+	return reflect.Int <= k && k <= reflect.Complex128
+}
+
+// The sign of a real number.
+func sign(v reflect.Value) int {
+	switch {
+	case v.CanInt():
+		return cmpZero(v.Int() > 0, v.Int() < 0)
+	case v.CanUint():
+		return cmpZero(v.Uint() > 0, false)
+	case v.CanFloat():
+		return cmpZero(v.Float() > 0, v.Float() < 0)
+	}
+	return 0
+}
+
+func cmpZero(pos, neg bool) int {
+	switch {
+	case pos:
+		return 1
+	case neg:
+		return -1
+	}
+	return 0
+}
+
+// Convert the number v to typ, if the conversion loses nothing.
+func convertExact(v reflect.Value, typ reflect.Type) (reflect.Value, bool) {
+	c := v.Convert(typ)
+	if !c.Convert(v.Type()).Equal(v) || sign(c) != sign(v) {
+		return v, false
+	}
+	return c, true
+}
+
+// Make v a value of type typ, if it is assignable or a number typ can
+// represent exactly.
+func as(v reflect.Value, typ reflect.Type) (reflect.Value, error) {
+	for v.Kind() == reflect.Interface && !v.IsNil() {
+		v = v.Elem()
+	}
+	switch {
+	case !v.IsValid() || v.Kind() == reflect.Interface:
+		return reflect.Zero(typ), nil
+	case v.Type().AssignableTo(typ):
+		return v, nil
+	case isNumeric(v.Kind()) && isNumeric(typ.Kind()) && v.Type().ConvertibleTo(typ):
+		if c, ok := convertExact(v, typ); ok {
+			return c, nil
+		}
+	}
+	return v, errorf("%v is not a %v", v.Type(), typ)
+}
+
+// The arguments of the row's call.
+func (c callElem) args() ([]reflect.Value, error) {
+	typ := c.f.fn.Type()
+	if typ.NumIn() == 0 {
+		return nil, nil
+	}
+	in, err := split(c.field("In"), typ.NumIn())
+	if err != nil {
+		return nil, errorf("In: %v", err)
+	}
+	for i := range in {
+		if in[i], err = as(in[i], typ.In(i)); err != nil {
+			return nil, errorf("argument %d: %v", i, err)
+		}
+	}
+	return in, nil
+}
+
+// Describes the row's call, e.g. `Atoi("12")`, or `Atoi(?)` when its arguments
+// can't be described. Rows are named outside of tests, so String must not
+// panic.
+func (c callElem) String() (s string) {
+	s = c.f.name + "(?)"
+	defer func() { recover() }()
+	args, err := c.args()
+	if err != nil {
+		return
+	}
+	var strs []string
+	for _, a := range args {
+		if !a.CanInterface() {
+			return
+		}
+		strs = append(strs, sprintf("%#v", a.Interface()))
+	}
+	return sprintf("%s(%s)", c.f.name, strings.Join(strs, ", "))
+}
+
+func (c callElem) Test(t T) {
+	args, err := c.args()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []reflect.Value
+	if c.f.fn.Type().IsVariadic() {
+		out = c.f.fn.CallSlice(args)
+	} else {
+		out = c.f.fn.Call(args)
+	}
+	types := resultTypes(c.f.fn.Type())
+	if len(out) > len(types) {
+		var exp ErrorExpectation
+		if e := c.field("Err"); e.IsValid() {
+			exp = e.Interface()
+		}
+		err, _ := out[len(out)-1].Interface().(error)
+		checkError(t, exp, err)
+		if err != nil {
+			return
+		}
+	}
+	if len(types) == 0 {
+		return
+	}
+	want, err := split(c.field("Want"), len(types))
+	if err != nil {
+		t.Fatalf("Want: %v", err)
+	}
+	for i, typ := range types {
+		w, err := as(want[i], typ)
+		if err != nil {
+			t.Errorf("Want: result %d: %v", i, err)
+			continue
+		}
+		if got := out[i].Interface(); !reflect.DeepEqual(got, w.Interface()) {
+			if len(types) == 1 {
+				t.Errorf("got %#v; want %#v", got, w.Interface())
+			} else {
+				t.Errorf("result %d: got %#v; want %#v", i, got, w.Interface())
+			}
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package table
+
+/*  Filename:    call_test.go
+ *  Description: For testing call.go
+ */
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func divmod(a, b int) (int, int) { return a / b, a % b }
+
+func sum(xs ...int64) int64 {
+	var n int64
+	for _, x := range xs {
+		n += x
+	}
+	return n
+}
+
+func TestFunc(t *testing.T) {
+	testWith(t, Func(strconv.Atoi, []struct {
+		In   string
+		Want int
+		Err  ErrorExpectation
+	}{
+		{"12", 12, nil},
+		{"-1", -1, nil},
+		{"x", 0, strconv.ErrSyntax},
+	}))
+	testWith(t, Func(divmod, []struct {
+		In   []int
+		Want struct{ Q, R int }
+	}{
+		{[]int{7, 2}, struct{ Q, R int }{3, 1}},
+	}))
+	testWith(t, Func(sum, []struct {
+		In   []int64
+		Want int
+	}{
+		{nil, 0},
+		{[]int64{1, 2, 3}, 6},
+	}))
+}
+
+// Parses numbers in a nested table.
+type atoiElem struct{}
+
+func (test atoiElem) Table() interface{} {
+	return Func(strconv.Atoi, []struct {
+		In   string
+		Want int
+	}{
+		{"1", 1},
+		{"2", 3},
+	})
+}
+
+func TestFuncNested(t *testing.T) {
+	rep := new(recordReporter)
+	ft := fauxTest("func", func(t T) { testWith(t, []atoiElem{{}}, Report(rep)) })
+	if len(rep.results) != 2 || !ft.failed || !ft.logLike(`func: table.atoiElem 0/strconv.Atoi\("2"\): got 2; want 3$`) {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+}
+
+func TestFuncFailures(t *testing.T) {
+	rep := new(recordReporter)
+	ft := fauxTest("func", func(t T) {
+		testWith(t, Func(divmod, []struct {
+			Name string
+			In   []interface{}
+			Want []interface{}
+		}{
+			{"", []interface{}{7, 2}, []interface{}{3, 0}},
+			{"named", []interface{}{7, "2"}, nil},
+			{"", []interface{}{7}, nil},
+		}), Report(rep))
+	})
+	var names []string
+	for _, res := range rep.results {
+		names = append(names, res.Name)
+	}
+	if sprint(names) != `[func: table.divmod(7, 2) func: named func: table.divmod(?)]` {
+		t.Errorf("unexpected names %q", names)
+	}
+	want := []string{
+		`^call_test.go:\d+: func: table.divmod\(7, 2\): result 1: got 1; want 0$`,
+		`^call_test.go:\d+: func: named: argument 1: string is not a int$`,
+		`^call_test.go:\d+: func: table.divmod\(\?\): In: 1 values; need 2$`,
+	}
+	if len(ft.log) != len(want) {
+		t.Fatalf("unexpected log %v", ft.log)
+	}
+	for i, patt := range want {
+		if !ft.logLineLike(i, patt) {
+			t.Errorf("log line %d %q doesn't match %q", i, ft.log[i], patt)
+		}
+	}
+}
+
+func TestFuncError(t *testing.T) {
+	ft := fauxTest("func", func(t T) {
+		testWith(t, Func(strconv.Atoi, []struct{ In, Want int }{{1, 1}}))
+	})
+	if !ft.logLike(`argument 0: int is not a string$`) {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+	ft = fauxTest("func", func(t T) {
+		testWith(t, Func(strconv.Atoi, []struct {
+			In   string
+			Want int
+		}{{"x", 0}, {"2", 3}}))
+	})
+	if !ft.logLike(`unexpected error: strconv.Atoi: parsing "x": invalid syntax$`) || !ft.logLike(`: got 2; want 3$`) {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+}
+
+func half(n int) int       { return n / 2 }
+func negate(n uint8) uint8 { return -n }
+
+func TestFuncLossyConversion(t *testing.T) {
+	ft := fauxTest("func", func(t T) {
+		testWith(t, Func(half, []struct {
+			In   int
+			Want float64
+		}{{5, 2.5}, {4, 2.0}}))
+	})
+	if len(ft.log) != 1 || !ft.logLike(`half\(5\): Want: result 0: float64 is not a int$`) {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+	ft = fauxTest("func", func(t T) {
+		testWith(t, Func(negate, []struct{ In, Want int }{{1, -1}, {0, 0}, {300, 44}}))
+	})
+	want := []string{`negate\(0x1\): Want: result 0: int is not a uint8$`, `argument 0: int is not a uint8$`}
+	if len(ft.log) != len(want) {
+		t.Fatalf("unexpected log %v", ft.log)
+	}
+	for i, patt := range want {
+		if !ft.logLineLike(i, patt) {
+			t.Errorf("log line %d %q doesn't match %q", i, ft.log[i], patt)
+		}
+	}
+}
+
+// Arguments in a struct with unexported fields.
+type divmodArgs struct{ a, b int }
+
+func TestFuncUnexported(t *testing.T) {
+	rep := new(recordReporter)
+	ft := fauxTest("func", func(t T) {
+		testWith(t, Func(divmod, []struct {
+			In   divmodArgs
+			Want struct{ Q, R int }
+		}{{divmodArgs{7, 2}, struct{ Q, R int }{3, 1}}}), Report(rep))
+	})
+	if len(rep.results) != 1 || rep.results[0].Name != "func: table.divmod(?)" {
+		t.Errorf("unexpected results %v", rep.results)
+	}
+	if !ft.failed || len(ft.log) != 1 || !ft.logLike(`In: table.divmodArgs has unexported field a$`) {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+}
+
+type funcPanicTest struct {
+	fn, rows interface{}
+	panic    string
+}
+
+func (test funcPanicTest) Test(t T) {
+	defer func() {
+		if e := recover(); !strings.Contains(sprint(e), test.panic) {
+			t.Errorf("unexpected panic %v", e)
+		}
+	}()
+	Func(test.fn, test.rows)
+	t.Errorf("no panic")
+}
+
+var funcPanicTests = []funcPanicTest{
+	{3, []struct{}{}, "int is not a function"},
+	{divmod, []int{}, "are not a slice of structs"},
+	{divmod, []struct{ Want int }{}, "have no In field"},
+	{divmod, []struct{ In int }{}, "have no Want field"},
+}
+
+func TestFuncPanics(t *testing.T) {
+	for i, test := range funcPanicTests {
+		elementTest(subT(sprintf("func panic %d", i), t), test)
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    codec.go
+ *  Description: Round-trip tables for encoders and decoders.
+ */
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+// An encoder and decoder pair. Decode should read exactly one encoded value.
+type Codec struct {
+	Encode func(w io.Writer, v interface{}) error
+	Decode func(r io.Reader) (interface{}, error)
+}
+
+// A value and its encoding.
+type CodecRow struct {
+	Value   interface{}
+	Encoded []byte
+}
+
+// A CodecRow bound to its Codec.
+type codecElement struct {
+	c Codec
+	CodecRow
+}
+
+func (e codecElement) String() string { return sprintf("codec %T", e.Value) }
+
+func (e codecElement) decode(t T, what string, p []byte) (v interface{}, ok bool) {
+	r := bytes.NewReader(p)
+	v, err := e.c.Decode(r)
+	if err != nil {
+		t.Errorf("%s: %v", what, err)
+		return nil, false
+	}
+	if n := r.Len(); n > 0 {
+		t.Errorf("%s: %d trailing bytes not read", what, n)
+	}
+	return v, true
+}
+
+// Check encode(v), decode(encoded), and decode(encode(v)).
+func (e codecElement) Test(t T) {
+	buf := new(bytes.Buffer)
+	encoded := true
+	if err := e.c.Encode(buf, e.Value); err != nil {
+		t.Errorf("encode: %v", err)
+		encoded = false
+	} else if !bytes.Equal(buf.Bytes(), e.Encoded) {
+		t.Errorf("encode: %#v encoded incorrectly\n%s", e.Value, hexDiff(buf.Bytes(), e.Encoded))
+	}
+	if v, ok := e.decode(t, "decode", e.Encoded); ok && !reflect.DeepEqual(v, e.Value) {
+		t.Errorf("decode: %#v, want %#v", v, e.Value)
+	}
+	if !encoded {
+		return
+	}
+	if v, ok := e.decode(t, "round trip", buf.Bytes()); ok && !reflect.DeepEqual(v, e.Value) {
+		t.Errorf("round trip: %#v, want %#v", v, e.Value)
+	}
+}
+
+// Bind rows to c, returning a table for Test.
+func (c Codec) Table(rows []CodecRow) []Element {
+	elems := make([]Element, len(rows))
+	for i, row := range rows {
+		elems[i] = codecElement{c, row}
+	}
+	return elems
+}
+
+// Test c with a table of rows. Each row checks that its value encodes to its
+// bytes, that its bytes decode to its value, and that the value survives a
+// round trip. Mismatched bytes are shown as a hex diff.
+func (c Codec) Test(t *testing.T, rows []CodecRow, opts ...Option) {
+	testWith(t, c.Table(rows), append([]Option{CallSite(1, 1)}, opts...)...)
+}
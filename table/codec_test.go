@@ -0,0 +1,52 @@
+package table
+
+/*  Filename:    codec_test.go
+ *  Description: For testing codec.go
+ */
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+var uvarintCodec = Codec{
+	Encode: func(w io.Writer, v interface{}) error {
+		_, err := w.Write(binary.AppendUvarint(nil, v.(uint64)))
+		return err
+	},
+	Decode: func(r io.Reader) (interface{}, error) {
+		br, ok := r.(io.ByteReader)
+		if !ok {
+			br = bufio.NewReader(r)
+		}
+		return binary.ReadUvarint(br)
+	},
+}
+
+func TestCodec(t *testing.T) {
+	uvarintCodec.Test(t, []CodecRow{
+		{uint64(0), []byte{0}},
+		{uint64(1), []byte{1}},
+		{uint64(300), []byte{0xac, 0x02}},
+	})
+}
+
+var codecFailureTests = []metaTestSimple{
+	{"wrong encoding", func(t T) { elementTest(t, codecElement{uvarintCodec, CodecRow{uint64(300), []byte{0xac, 0x03}}}) },
+		[]string{`encode: 0x12c encoded incorrectly\n- 00000000  ac 03`, `decode: 0x1ac, want 0x12c`}},
+	{"trailing bytes", func(t T) { elementTest(t, codecElement{uvarintCodec, CodecRow{uint64(1), []byte{1, 0}}}) },
+		[]string{`decode: 1 trailing bytes not read`}},
+	{"bad value", func(t T) { elementTest(t, codecElement{uvarintCodec, CodecRow{uint64(1), []byte{0x80}}}) },
+		[]string{`decode: unexpected EOF`}},
+}
+
+func TestCodecFailures(t *testing.T) {
+	for i, test := range codecFailureTests {
+		elementTest(subT(sprintf("codec failure %d", i), t), test)
+	}
+	if s := sprint(uvarintCodec.Table([]CodecRow{{uint64(1), nil}})[0]); s != "codec uint64" {
+		t.Errorf("unexpected name %q", s)
+	}
+}
@@ -0,0 +1,253 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    coverage.go
+ *  Description: Coverage contributed by individual elements.
+ */
+
+import (
+	"bufio"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Log the source lines each element covers, and flag redundant elements,
+// which could be removed from the table without losing coverage. Coverage
+// must be enabled (go test -cover).
+//
+// The runtime/coverage package can't read counters while a test binary runs,
+// so when the table finishes the enclosing Go test is run again by the test
+// binary once for each element, testing only that element, and once testing
+// none of them, each time writing a coverage profile. An element covers the
+// blocks of statements covered by its run but not by the run testing no
+// elements. Statements of this package are ignored. The Go test must not
+// depend on other Go tests having run before it.
+//
+// An element is redundant when every block it covers is covered by another
+// element of the table which is not redundant. Elements are considered last
+// to first, so of elements covering the same blocks only the first is kept.
+// Elements covering nothing are redundant.
+func Coverage() Option { return func(c *config) { c.coverage = true } }
+
+// A block of statements instrumented for coverage, in a file named by its
+// package's import path (e.g. "example.com/pkg/file.go").
+type coverBlock struct {
+	file               string
+	startLine, endLine int
+	startCol, endCol   int
+}
+
+// Reports the blocks covered by the Go test named test when only the element
+// named elem, of the table tested at site, is tested.
+type coverFunc func(test, site, elem string) ([]coverBlock, error)
+
+// Set in the environment of a test binary measuring the coverage of an
+// element, to the site of the call testing its table and its name.
+const (
+	coverSiteEnv = "TABLE_COVER_SITE"
+	coverElemEnv = "TABLE_COVER_ELEMENT"
+)
+
+// Reports whether the test binary only measures the coverage of an element.
+func coverChild() bool {
+	_, ok := os.LookupEnv(coverElemEnv)
+	return ok
+}
+
+// Reports whether the element named name should be skipped, as the test
+// binary only measures the coverage of another element.
+func (c *config) coverSkip(name string) bool {
+	return coverChild() && (os.Getenv(coverElemEnv) != name || os.Getenv(coverSiteEnv) != c.site)
+}
+
+// The import path of this package, whose statements elements don't cover.
+var tablePkg = reflect.TypeOf(config{}).PkgPath()
+
+// Run the Go test named test in the test binary, testing only the element
+// named elem of the table tested at site, and read its coverage profile.
+func coverRun(test, site, elem string) ([]coverBlock, error) {
+	if testing.CoverMode() == "" {
+		return nil, error_("not enabled (run go test with -cover)")
+	}
+	dir, err := os.MkdirTemp("", "table-cover")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	profile := filepath.Join(dir, "cover.out")
+	cmd := exec.Command(os.Args[0], "-test.run="+runPattern(test), "-test.count=1",
+		"-test.coverprofile="+profile, "-test.gocoverdir="+dir)
+	cmd.Env = append(os.Environ(), coverSiteEnv+"="+site, coverElemEnv+"="+elem)
+	out, err := cmd.CombinedOutput()
+	f, ferr := os.Open(profile)
+	if ferr != nil {
+		if err == nil {
+			err = ferr
+		}
+		return nil, errorf("running %s: %v\n%s", test, err, out)
+	}
+	defer f.Close()
+	return readProfile(f)
+}
+
+// A -test.run pattern matching only the Go test named name.
+func runPattern(name string) string {
+	parts := strings.Split(name, "/")
+	for i, p := range parts {
+		parts[i] = "^" + regexp.QuoteMeta(p) + "$"
+	}
+	return strings.Join(parts, "/")
+}
+
+var profileLine = regexp.MustCompile(`^(.+):(\d+)\.(\d+),(\d+)\.(\d+) \d+ (\d+)$`)
+
+// The covered blocks of a coverage profile (see go tool cover).
+func readProfile(f *os.File) (blocks []coverBlock, err error) {
+	seen := make(map[coverBlock]bool)
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		m := profileLine.FindStringSubmatch(s.Text())
+		if m == nil || m[6] == "0" {
+			continue
+		}
+		b := coverBlock{file: m[1]}
+		b.startLine, _ = strconv.Atoi(m[2])
+		b.startCol, _ = strconv.Atoi(m[3])
+		b.endLine, _ = strconv.Atoi(m[4])
+		b.endCol, _ = strconv.Atoi(m[5])
+		if !seen[b] {
+			seen[b] = true
+			blocks = append(blocks, b)
+		}
+	}
+	return blocks, s.Err()
+}
+
+// Measure the blocks covered by each element of a table tested by t, and
+// flag redundant elements. Returns an error if coverage can't be measured.
+func measureCoverage(t *testingT, results []Result) error {
+	named, ok := t.t.(interface {
+		Name() string
+	})
+	if !ok {
+		return error_("the Go test has no name")
+	}
+	run := t.cfg.cover
+	if run == nil {
+		run = coverRun
+	}
+	base, err := run(named.Name(), t.cfg.site, "")
+	if err != nil {
+		return err
+	}
+	setup := make(map[coverBlock]bool)
+	for _, b := range base {
+		setup[b] = true
+	}
+	for i := range results {
+		blocks, err := run(named.Name(), t.cfg.site, results[i].Name)
+		if err != nil {
+			return err
+		}
+		for _, b := range blocks {
+			if !setup[b] && path.Dir(b.file) != tablePkg {
+				results[i].blocks = append(results[i].blocks, b)
+			}
+		}
+		results[i].Coverage = coverLines(results[i].blocks)
+	}
+	markRedundant(results)
+	return nil
+}
+
+// The lines covered by blocks, by file (e.g. "table.go:10-12,15").
+func coverLines(blocks []coverBlock) (lines []string) {
+	files := make(map[string]map[int]bool)
+	for _, b := range blocks {
+		name := path.Base(b.file)
+		if files[name] == nil {
+			files[name] = make(map[int]bool)
+		}
+		for i := b.startLine; i <= b.endLine; i++ {
+			files[name][i] = true
+		}
+	}
+	for name, set := range files {
+		var nums []int
+		for i := range set {
+			nums = append(nums, i)
+		}
+		sort.Ints(nums)
+		var ranges []string
+		for i := 0; i < len(nums); {
+			j := i
+			for j+1 < len(nums) && nums[j+1] == nums[j]+1 {
+				j++
+			}
+			if r := sprint(nums[i]); i == j {
+				ranges = append(ranges, r)
+			} else {
+				ranges = append(ranges, r+"-"+sprint(nums[j]))
+			}
+			i = j + 1
+		}
+		lines = append(lines, name+":"+strings.Join(ranges, ","))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// Flag the redundant elements of a table (see Coverage).
+func markRedundant(results []Result) {
+	count := make(map[coverBlock]int)
+	for _, res := range results {
+		for _, b := range res.blocks {
+			count[b]++
+		}
+	}
+	for i := len(results) - 1; i >= 0; i-- {
+		res := &results[i]
+		res.Redundant = true
+		for _, b := range res.blocks {
+			if count[b] < 2 {
+				res.Redundant = false
+			}
+		}
+		if res.Redundant {
+			for _, b := range res.blocks {
+				count[b]--
+			}
+		}
+	}
+}
+
+// Log the lines covered by each element and whether it is redundant.
+func logCoverage(t *testingT, s Summary) {
+	if err := measureCoverage(t, s.Results); err != nil {
+		t.Logf("cover: %v", err)
+		return
+	}
+	for _, res := range s.Results {
+		name := strings.TrimPrefix(res.Name, t.cfg.prefix)
+		lines := strings.Join(res.Coverage, " ")
+		if lines == "" {
+			lines = "nothing"
+		}
+		if res.Redundant {
+			t.Logf("cover: %s: redundant, covers %s", name, lines)
+		} else {
+			t.Logf("cover: %s: covers %s", name, lines)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package table
+
+/*  Filename:    coverage_test.go
+ *  Description: For testing coverage.go
+ */
+
+import (
+	"path"
+	"runtime"
+	"testing"
+)
+
+// Covers the lines of x.go it holds (see fakeCoverage).
+type coverElem []int
+
+func (test coverElem) Test(t T) {}
+
+// A fauxT with the name of a Go test.
+type namedT struct{ *fauxT }
+
+func (t namedT) Name() string { return "TestX" }
+
+// Reports the blocks covered by coverElems, or err.
+func fakeCoverage(elems []coverElem, err error) Option {
+	setup := coverBlock{file: "example.com/x/x.go", startLine: 9, endLine: 9}
+	return func(c *config) {
+		c.cover = func(test, site, elem string) ([]coverBlock, error) {
+			blocks := []coverBlock{setup}
+			for i, lines := range elems {
+				if elem != sprintf("table.coverElem %d", i) {
+					continue
+				}
+				for _, line := range lines {
+					blocks = append(blocks, coverBlock{file: setup.file, startLine: line, endLine: line})
+				}
+			}
+			return blocks, err
+		}
+	}
+}
+
+func TestCoverage(t *testing.T) {
+	rep := new(recordReporter)
+	elems := []coverElem{{1, 2, 3, 5}, {2}, {9, 7}, {}, {1, 2, 3, 5}}
+	ft := new(fauxT)
+	testWith(namedT{ft}, elems, Coverage(), Report(rep), fakeCoverage(elems, nil))
+	want := []string{
+		"cover: table.coverElem 0: covers x.go:1-3,5",
+		"cover: table.coverElem 1: redundant, covers x.go:2",
+		"cover: table.coverElem 2: covers x.go:7",
+		"cover: table.coverElem 3: redundant, covers nothing",
+		"cover: table.coverElem 4: redundant, covers x.go:1-3,5",
+	}
+	if got := sprint(ft.log); got != sprint(want) {
+		t.Errorf("unexpected log %v", got)
+	}
+	if ft.failed {
+		t.Error("coverage failed the table")
+	}
+	var redundant []bool
+	for _, res := range rep.summary.Results {
+		redundant = append(redundant, res.Redundant)
+	}
+	if sprint(redundant) != "[false true false true true]" {
+		t.Errorf("unexpected redundant elements %v", redundant)
+	}
+}
+
+func TestCoverageDisabled(t *testing.T) {
+	err := error_("not enabled (run go test with -cover)")
+	ft := new(fauxT)
+	testWith(namedT{ft}, []coverElem{{1}}, Coverage(), fakeCoverage(nil, err))
+	want := []string{"cover: not enabled (run go test with -cover)"}
+	if got := sprint(ft.log); got != sprint(want) {
+		t.Errorf("unexpected log %v", got)
+	}
+	ft = fauxTest("cover", func(t T) { testWith(t, []coverElem{{1}}, Coverage(), fakeCoverage(nil, nil)) })
+	want = []string{"cover: cover: the Go test has no name"}
+	if got := sprint(ft.log); got != sprint(want) {
+		t.Errorf("unexpected log %v", got)
+	}
+}
+
+// Calls lineDiff when it holds true.
+type diffCallElem bool
+
+func (test diffCallElem) Test(t T) {
+	if test {
+		lineDiff("a", "b")
+	}
+}
+
+// Tests a table of diffCallElems, returning the site of the call to testWith.
+func coverHelper(t T) string {
+	_, file, line, _ := runtime.Caller(0)
+	testWith(t, []diffCallElem{false, true}, callSite(0))
+	return sprintf("%s:%d", file, line+1)
+}
+
+func TestCoverageHelper(t *testing.T) { coverHelper(t) }
+
+// Runs TestCoverageHelper in a child process testing a single element.
+func TestCoverageRun(t *testing.T) {
+	if testing.CoverMode() == "" {
+		t.Skip("coverage not enabled")
+	}
+	site := coverHelper(new(fauxT))
+	covered := func(elem string) (n int) {
+		blocks, err := coverRun("TestCoverageHelper", site, elem)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, b := range blocks {
+			if path.Base(b.file) == "diff.go" {
+				n++
+			}
+		}
+		return n
+	}
+	if n := covered("table.diffCallElem 0"); n != 0 {
+		t.Errorf("element 0 covered %d blocks of diff.go", n)
+	}
+	if n := covered("table.diffCallElem 1"); n == 0 {
+		t.Error("element 1 covered no blocks of diff.go")
+	}
+}
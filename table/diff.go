@@ -0,0 +1,140 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    diff.go
+ *  Description: Differences between expected and actual output.
+ */
+
+import (
+	"bytes"
+	"strings"
+)
+
+const hexWidth = 16 // Bytes per line of a hex dump.
+
+// A line of a hex dump, like the output of "hexdump -C".
+func hexLine(off int, p []byte) string {
+	var hex, text strings.Builder
+	for i := 0; i < hexWidth; i++ {
+		switch {
+		case i >= len(p):
+			hex.WriteString("   ")
+		default:
+			hex.WriteString(sprintf("%02x ", p[i]))
+			if c := p[i]; c >= ' ' && c <= '~' {
+				text.WriteByte(c)
+			} else {
+				text.WriteByte('.')
+			}
+		}
+		if i == hexWidth/2-1 {
+			hex.WriteByte(' ')
+		}
+	}
+	return sprintf("%08x  %s |%s|", off, hex.String(), text.String())
+}
+
+func hexChunk(p []byte, off int) []byte {
+	switch {
+	case off >= len(p):
+		return nil
+	case off+hexWidth > len(p):
+		return p[off:]
+	}
+	return p[off : off+hexWidth]
+}
+
+// A hex dump of want and got, showing lines of want which differ from got
+// prefixed with "-", lines of got which differ from want prefixed with "+",
+// and one line of unchanged context around each difference.
+func hexDiff(got, want []byte) string {
+	n := len(got)
+	if len(want) > n {
+		n = len(want)
+	}
+	rows := (n + hexWidth - 1) / hexWidth
+	differ := make([]bool, rows)
+	for i := range differ {
+		differ[i] = !bytes.Equal(hexChunk(got, i*hexWidth), hexChunk(want, i*hexWidth))
+	}
+	var lines []string
+	elided := false
+	for i := 0; i < rows; i++ {
+		off := i * hexWidth
+		g, w := hexChunk(got, off), hexChunk(want, off)
+		switch {
+		case differ[i]:
+			if w != nil {
+				lines = append(lines, "- "+hexLine(off, w))
+			}
+			if g != nil {
+				lines = append(lines, "+ "+hexLine(off, g))
+			}
+		case i > 0 && differ[i-1], i+1 < rows && differ[i+1]:
+			lines = append(lines, "  "+hexLine(off, g))
+		default:
+			if !elided {
+				lines = append(lines, "  ...")
+			}
+			elided = true
+			continue
+		}
+		elided = false
+	}
+	return strings.Join(lines, "\n")
+}
+
+// A diff of the lines of want and got, showing lines only in want prefixed
+// with "-", lines only in got prefixed with "+", and one line of unchanged
+// context around each difference.
+func lineDiff(got, want string) string {
+	g, w := strings.Split(got, "\n"), strings.Split(want, "\n")
+	// lcs[i][j] is the length of the longest common subsequence of w[i:] and g[j:].
+	lcs := make([][]int, len(w)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(g)+1)
+	}
+	for i := len(w) - 1; i >= 0; i-- {
+		for j := len(g) - 1; j >= 0; j-- {
+			switch {
+			case w[i] == g[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	var ops []string
+	i, j := 0, 0
+	for i < len(w) || j < len(g) {
+		switch {
+		case i < len(w) && j < len(g) && w[i] == g[j]:
+			ops = append(ops, "  "+w[i])
+			i, j = i+1, j+1
+		case i < len(w) && (j == len(g) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, "- "+w[i])
+			i++
+		default:
+			ops = append(ops, "+ "+g[j])
+			j++
+		}
+	}
+	changed := func(k int) bool { return k >= 0 && k < len(ops) && ops[k][0] != ' ' }
+	var lines []string
+	elided := false
+	for k, op := range ops {
+		if changed(k) || changed(k-1) || changed(k+1) {
+			lines = append(lines, op)
+			elided = false
+		} else if !elided {
+			lines = append(lines, "  ...")
+			elided = true
+		}
+	}
+	return strings.Join(lines, "\n")
+}
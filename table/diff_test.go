@@ -0,0 +1,70 @@
+package table
+
+/*  Filename:    diff_test.go
+ *  Description: For testing diff.go
+ */
+
+import (
+	"bytes"
+	"testing"
+)
+
+type hexDiffTest struct {
+	got, want []byte
+	out       string
+}
+
+func (test hexDiffTest) Test(t T) {
+	if out := hexDiff(test.got, test.want); out != test.out {
+		t.Errorf("hexDiff(%q, %q) =>\n%s\nwant\n%s", test.got, test.want, out, test.out)
+	}
+}
+
+var hexDiffTests = []hexDiffTest{
+	{[]byte("abc"), []byte("abd"), "" +
+		"- 00000000  61 62 64                                          |abd|\n" +
+		"+ 00000000  61 62 63                                          |abc|"},
+	{[]byte("abc\x00"), []byte("abc"), "" +
+		"- 00000000  61 62 63                                          |abc|\n" +
+		"+ 00000000  61 62 63 00                                       |abc.|"},
+	{append(bytes.Repeat([]byte("x"), 64), 'y'), bytes.Repeat([]byte("x"), 64), "" +
+		"  ...\n" +
+		"  00000030  78 78 78 78 78 78 78 78  78 78 78 78 78 78 78 78  |xxxxxxxxxxxxxxxx|\n" +
+		"+ 00000040  79                                                |y|"},
+	{bytes.Repeat([]byte("x"), 48), append([]byte("X"), bytes.Repeat([]byte("x"), 47)...), "" +
+		"- 00000000  58 78 78 78 78 78 78 78  78 78 78 78 78 78 78 78  |Xxxxxxxxxxxxxxxx|\n" +
+		"+ 00000000  78 78 78 78 78 78 78 78  78 78 78 78 78 78 78 78  |xxxxxxxxxxxxxxxx|\n" +
+		"  00000010  78 78 78 78 78 78 78 78  78 78 78 78 78 78 78 78  |xxxxxxxxxxxxxxxx|\n" +
+		"  ..."},
+}
+
+func TestHexDiff(t *testing.T) {
+	for i, test := range hexDiffTests {
+		elementTest(subT(sprintf("hexDiff %d", i), t), test)
+	}
+}
+
+type lineDiffTest struct {
+	got, want string
+	out       string
+}
+
+func (test lineDiffTest) Test(t T) {
+	if out := lineDiff(test.got, test.want); out != test.out {
+		t.Errorf("lineDiff(%q, %q) =>\n%s\nwant\n%s", test.got, test.want, out, test.out)
+	}
+}
+
+var lineDiffTests = []lineDiffTest{
+	{"a\nb\nc", "a\nx\nc", "  a\n- x\n+ b\n  c"},
+	{"a\nb\nc\nd\ne\nf", "a\nb\nc\nd\ne", "  ...\n  e\n+ f"},
+	{"b\nc\nd\ne", "a\nb\nc\nd\ne", "- a\n  b\n  ..."},
+	{"a\nb", "a\nb", "  ..."},
+	{"", "a", "- a\n+ "},
+}
+
+func TestLineDiff(t *testing.T) {
+	for i, test := range lineDiffTests {
+		elementTest(subT(sprintf("lineDiff %d", i), t), test)
+	}
+}
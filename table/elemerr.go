@@ -0,0 +1,91 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    elemerr.go
+ *  Description: Elements returning errors.
+ */
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+)
+
+// The error expected from an ElementError. Underlying type must be nil (no
+// error is expected), error (checked with errors.Is), reflect.Type (an error
+// type, checked with errors.As), string, *regexp.Regexp or func(T, error).
+// String and regexp values test against the error's message, as they do for
+// PanicExpectation.
+type ErrorExpectation interface{}
+
+// An element whose Test method returns an error, and which declares the error
+// it expects. Errors not matching the expectation fail the element. An
+// ElementError may have Before and After methods, like an ElementBeforeAfter.
+type ElementError interface {
+	Test(T) error              // Execute the test described by the object.
+	WantErr() ErrorExpectation // The error Test is expected to return.
+}
+
+// An ElementError as an Element.
+type errorElem struct {
+	hooks
+	elem ElementError
+}
+
+func (e errorElem) Test(t T) {
+	err := e.elem.Test(t)
+	checkError(t, e.elem.WantErr(), err)
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// Fail t if err doesn't satisfy exp.
+func checkError(t T, exp ErrorExpectation, err error) {
+	switch exp := exp.(type) {
+	case nil:
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+		return
+	case func(T, error):
+		exp(subT("callback function", t), err)
+		return
+	}
+	if err == nil {
+		t.Errorf("no error; expected %v", describeError(exp))
+		return
+	}
+	switch exp := exp.(type) {
+	case error:
+		if !errors.Is(err, exp) {
+			t.Errorf("unexpected error (is not %v): %v", exp, err)
+		}
+	case reflect.Type:
+		if !exp.Implements(errorType) {
+			t.Errorf("unacceptable ErrorExpectation type %v (not an error)", exp)
+			return
+		}
+		if !errors.As(err, reflect.New(exp).Interface()) {
+			t.Errorf("unexpected error (no %v in chain): %v", exp, err)
+		}
+	case string, *regexp.Regexp:
+		if m := mismatch(exp, err.Error()); m != "" {
+			t.Errorf("unexpected error (%s): %v", m, err)
+		}
+	default:
+		t.Errorf("unacceptable ErrorExpectation type %s", reflect.TypeOf(exp))
+	}
+}
+
+func describeError(exp ErrorExpectation) string {
+	switch exp := exp.(type) {
+	case reflect.Type:
+		return sprintf("a %v", exp)
+	case string:
+		return sprintf("%#v", exp)
+	}
+	return sprint(exp)
+}
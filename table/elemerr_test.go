@@ -0,0 +1,135 @@
+package table
+
+/*  Filename:    elemerr_test.go
+ *  Description: For testing elemerr.go
+ */
+
+import (
+	"io/fs"
+	"os"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+var errSentinel = error_("sentinel")
+
+// Returns err, expecting want.
+type errElem struct {
+	err  error
+	want ErrorExpectation
+}
+
+func (test errElem) Test(t T) error            { return test.err }
+func (test errElem) WantErr() ErrorExpectation { return test.want }
+
+var pathError = &fs.PathError{Op: "open", Path: "x", Err: os.ErrNotExist}
+
+var errElemTests = []metaTestSimple{
+	{"nil", func(t T) { elementTest(t, errorElem{hooks{}, errElem{nil, nil}}) }, nil},
+	{"unexpected", func(t T) { elementTest(t, errorElem{hooks{}, errElem{errSentinel, nil}}) },
+		[]string{`^simple meta-test: unexpected error: sentinel$`}},
+	{"missing", func(t T) { elementTest(t, errorElem{hooks{}, errElem{nil, errSentinel}}) },
+		[]string{`^simple meta-test: no error; expected sentinel$`}},
+	{"is", func(t T) {
+		elementTest(t, errorElem{hooks{}, errElem{errorf("wrapped: %w", errSentinel), errSentinel}})
+	}, nil},
+	{"is not", func(t T) { elementTest(t, errorElem{hooks{}, errElem{os.ErrExist, errSentinel}}) },
+		[]string{`^simple meta-test: unexpected error \(is not sentinel\): file already exists$`}},
+	{"as", func(t T) {
+		elementTest(t, errorElem{hooks{}, errElem{errorf("wrapped: %w", pathError), reflect.TypeOf(pathError)}})
+	}, nil},
+	{"as missing", func(t T) { elementTest(t, errorElem{hooks{}, errElem{errSentinel, reflect.TypeOf(pathError)}}) },
+		[]string{`^simple meta-test: unexpected error \(no \*fs.PathError in chain\): sentinel$`}},
+	{"no error of type", func(t T) { elementTest(t, errorElem{hooks{}, errElem{nil, reflect.TypeOf(pathError)}}) },
+		[]string{`^simple meta-test: no error; expected a \*fs.PathError$`}},
+	{"not an error type", func(t T) { elementTest(t, errorElem{hooks{}, errElem{errSentinel, reflect.TypeOf(0)}}) },
+		[]string{`unacceptable ErrorExpectation type int \(not an error\)$`}},
+	{"string", func(t T) { elementTest(t, errorElem{hooks{}, errElem{errSentinel, "sent"}}) }, nil},
+	{"string mismatch", func(t T) { elementTest(t, errorElem{hooks{}, errElem{errSentinel, "other"}}) },
+		[]string{`^simple meta-test: unexpected error \(doesn't contain "other"\): sentinel$`}},
+	{"regexp", func(t T) { elementTest(t, errorElem{hooks{}, errElem{errSentinel, regexp.MustCompile(`^s.*l$`)}}) }, nil},
+	{"regexp mismatch", func(t T) { elementTest(t, errorElem{hooks{}, errElem{errSentinel, regexp.MustCompile(`^x`)}}) },
+		[]string{`^simple meta-test: unexpected error \(doesn't match \^x\): sentinel$`}},
+	{"callback", func(t T) {
+		elementTest(t, errorElem{hooks{}, errElem{errSentinel, func(t T, err error) { t.Errorf("got %v", err) }}})
+	}, []string{`^simple meta-test: callback function: got sentinel$`}},
+	{"unacceptable", func(t T) { elementTest(t, errorElem{hooks{}, errElem{errSentinel, 3}}) },
+		[]string{`^simple meta-test: unacceptable ErrorExpectation type int$`}},
+}
+
+func TestElementError(t *testing.T) {
+	for i, test := range errElemTests {
+		elementTest(subT(sprintf("element error %d", i), t), test)
+	}
+}
+
+// An ElementError panicking with its value, if non-nil, and expecting panics.
+type panicErrElem struct {
+	v    interface{}
+	exps []PanicExpectation
+}
+
+func (test panicErrElem) Test(t T) error {
+	if test.v != nil {
+		panic(test.v)
+	}
+	return nil
+}
+func (test panicErrElem) WantErr() ErrorExpectation  { return nil }
+func (test panicErrElem) Panics() []PanicExpectation { return test.exps }
+
+// Test elem after making it an Element.
+func testWrapped(elem interface{}) func(T) {
+	return func(t T) {
+		if test, err := mustElement(t, elem); err == nil {
+			elementTest(t, test)
+		}
+	}
+}
+
+var panicErrElemTests = []metaTestSimple{
+	{"expected", testWrapped(panicErrElem{"boom", []PanicExpectation{"boom"}}), nil},
+	{"mismatch", testWrapped(panicErrElem{"boom", []PanicExpectation{"bang"}}),
+		[]string{`^simple meta-test: .*bang`}},
+	{"missing", testWrapped(panicErrElem{nil, []PanicExpectation{"boom"}}),
+		[]string{`^simple meta-test: test did not panic as expected \[boom\]$`}},
+	{"unexpected", testWrapped(panicErrElem{"boom", nil}),
+		[]string{`^simple meta-test: unexpected panic: boom\n`}},
+}
+
+func TestElementErrorPanics(t *testing.T) {
+	for i, test := range panicErrElemTests {
+		elementTest(subT(sprintf("element error panics %d", i), t), test)
+	}
+}
+
+// An ElementError with hooks, metadata and an expected failure.
+type hookedErrElem struct {
+	calls *[]string
+	owner string `table:"owner"`
+}
+
+func (test hookedErrElem) Before(t T)                { *test.calls = append(*test.calls, "before") }
+func (test hookedErrElem) After(t T)                 { *test.calls = append(*test.calls, "after") }
+func (test hookedErrElem) Test(t T) error            { *test.calls = append(*test.calls, "test"); return nil }
+func (test hookedErrElem) WantErr() ErrorExpectation { return errSentinel }
+func (test hookedErrElem) XFail() string             { return "#7" }
+
+func TestElementErrorTable(t *testing.T) {
+	var calls []string
+	rep := new(recordReporter)
+	ft := fauxTest("err", func(t T) {
+		testWith(t, []interface{}{errElem{errSentinel, errSentinel}, hookedErrElem{&calls, "alice"}}, Report(rep))
+	})
+	if ft.failed {
+		t.Errorf("unexpected failure %v", ft.log)
+	}
+	if sprint(calls) != "[before test after]" {
+		t.Errorf("unexpected calls %v", calls)
+	}
+	res := rep.results[1]
+	if res.XFail != "#7" || res.Meta == nil || res.Meta.Owner != "alice" {
+		t.Errorf("unexpected result %#v", res)
+	}
+}
@@ -0,0 +1,148 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    format.go
+ *  Description: Pluggable formatting of logged messages.
+ */
+
+import (
+	"encoding/json"
+	"os"
+)
+
+// A message logged through a T, before formatting.
+type Message struct {
+	Name     string `json:"name,omitempty"`     // Name of the test logging the message (possibly empty).
+	Location string `json:"location,omitempty"` // File and line declaring the element tested (possibly empty).
+	Kind     string `json:"kind"`               // One of "log", "error", or "fatal".
+	Text     string `json:"text"`               // The message itself.
+}
+
+// A Formatter lays out messages logged by table tests. Select one for a
+// single call to Test with the Format option.
+type Formatter interface {
+	Format(m Message) string
+}
+
+// An adapter allowing ordinary functions to be used as Formatters.
+type FormatterFunc func(Message) string
+
+func (fn FormatterFunc) Format(m Message) string { return fn(m) }
+
+func layout(format, loc, name, text string) string {
+	if format == "" {
+		format = "%s: %s"
+	}
+	if name != "" {
+		text = sprintf(format, name, text)
+	}
+	return located(loc, text)
+}
+
+// Prepend a location to formatted text.
+func located(loc, text string) string {
+	if loc != "" {
+		return loc + ": " + text
+	}
+	return text
+}
+
+// Formats messages as "file:line: name: text". Layout, when non-empty, replaces the
+// 2-argument format string used to join the name and the text.
+type PlainFormatter struct{ Layout string }
+
+func (f PlainFormatter) Format(m Message) string { return layout(f.Layout, m.Location, m.Name, m.Text) }
+
+// Like PlainFormatter, but errors are labeled as such ("name error: text").
+type VerboseFormatter struct{ Layout string }
+
+func (f VerboseFormatter) Format(m Message) string {
+	name := m.Name
+	if m.Kind != "log" {
+		name = msgname(name, m.Kind)
+		if m.Name == "" {
+			name = m.Kind
+		}
+	}
+	return layout(f.Layout, m.Location, name, m.Text)
+}
+
+// ANSI escape sequences used by ColorFormatter.
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiRed   = "\x1b[31m"
+	ansiFatal = "\x1b[1;35m"
+)
+
+// Formats messages like PlainFormatter, highlighting names and coloring
+// errors with ANSI escape sequences. Meant for terminals.
+type ColorFormatter struct{}
+
+func (f ColorFormatter) Format(m Message) string {
+	text := m.Text
+	switch m.Kind {
+	case "error":
+		text = ansiRed + text + ansiReset
+	case "fatal":
+		text = ansiFatal + text + ansiReset
+	}
+	if m.Name != "" {
+		text = ansiBold + m.Name + ansiReset + ": " + text
+	}
+	return located(m.Location, text)
+}
+
+// Formats each message as a single line JSON object with keys "name",
+// "location", "kind" and "text".
+type JSONFormatter struct{}
+
+func (f JSONFormatter) Format(m Message) string {
+	p, err := json.Marshal(m)
+	if err != nil {
+		return sprint(m)
+	}
+	return string(p)
+}
+
+// Returns a ColorFormatter when f is a terminal and a PlainFormatter
+// otherwise.
+func TerminalFormatter(f *os.File) Formatter {
+	if info, err := f.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+		return ColorFormatter{}
+	}
+	return PlainFormatter{}
+}
+
+// The layout used when no Formatter is given to Test, which honors the
+// deprecated Verbose and MsgFmt globals. The names a message was logged
+// under wrap it in turn, innermost first, as when every T passed messages to
+// its parent. Under Verbose each name labels errors with their kind, except
+// the innermost when the message was not labeled (i.e. Errorf and Fatalf).
+func legacyFormat(names []string, kind, text string, labeled bool) string {
+	for i := len(names) - 1; i >= 0; i-- {
+		if kind == "log" || i == len(names)-1 && !labeled {
+			text = msg(names[i], text)
+		} else {
+			text = legacyErrmsg(names[i], kind, text)
+		}
+	}
+	return text
+}
+
+func legacyErrmsg(name, typ, m string) string {
+	switch {
+	case Verbose && name != "":
+		typ = sprintf("%s %s", name, typ)
+		fallthrough
+	case Verbose:
+		name = msg(name, typ)
+		fallthrough
+	case name != "":
+		m = msg(name, m)
+	}
+	return m
+}
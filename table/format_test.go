@@ -0,0 +1,142 @@
+package table
+
+/*  Filename:    format_test.go
+ *  Description: For testing format.go
+ */
+
+import (
+	"testing"
+)
+
+type formatTest struct {
+	f   Formatter
+	m   Message
+	out string
+}
+
+func (test formatTest) Test(t T) {
+	if out := test.f.Format(test.m); out != test.out {
+		t.Errorf("%#v.Format(%#v) => %#v != %#v", test.f, test.m, out, test.out)
+	}
+}
+
+var formatTests = []formatTest{
+	{PlainFormatter{}, Message{"a: b", "", "log", "hello"}, "a: b: hello"},
+	{PlainFormatter{}, Message{"a", "", "error", "hello"}, "a: hello"},
+	{PlainFormatter{}, Message{"", "", "error", "hello"}, "hello"},
+	{PlainFormatter{}, Message{"a", "x_test.go:3", "error", "hello"}, "x_test.go:3: a: hello"},
+	{PlainFormatter{"[%s] %s"}, Message{"a", "", "fatal", "hello"}, "[a] hello"},
+	{VerboseFormatter{}, Message{"a", "", "log", "hello"}, "a: hello"},
+	{VerboseFormatter{}, Message{"a", "", "error", "hello"}, "a error: hello"},
+	{VerboseFormatter{}, Message{"a", "", "fatal", "hello"}, "a fatal: hello"},
+	{VerboseFormatter{}, Message{"", "", "error", "hello"}, "error: hello"},
+	{ColorFormatter{}, Message{"a", "", "log", "hello"}, "\x1b[1ma\x1b[0m: hello"},
+	{ColorFormatter{}, Message{"", "", "error", "hello"}, "\x1b[31mhello\x1b[0m"},
+	{JSONFormatter{}, Message{"a", "", "error", "hello"}, `{"name":"a","kind":"error","text":"hello"}`},
+	{JSONFormatter{}, Message{"", "", "log", "hello"}, `{"kind":"log","text":"hello"}`},
+	{JSONFormatter{}, Message{"a", "x_test.go:3", "log", "hello"}, `{"name":"a","location":"x_test.go:3","kind":"log","text":"hello"}`},
+	{FormatterFunc(func(m Message) string { return m.Kind }), Message{"a", "", "fatal", "hello"}, "fatal"},
+}
+
+func TestFormat(t *testing.T) {
+	for i, test := range formatTests {
+		elementTest(subT(sprintf("format %d", i), t), test)
+	}
+}
+
+type formatOptionElem string
+
+func (test formatOptionElem) Test(t T) { t.Error(string(test)) }
+
+func TestFormatOption(t *testing.T) {
+	ft := fauxTest("format option", func(t T) {
+		testWith(t, []formatOptionElem{"hello"}, Format(JSONFormatter{}))
+	})
+	if !ft.failed {
+		t.Error("element error did not fail the test")
+	}
+	if want := `{"name":"format option: table.formatOptionElem 0","kind":"error","text":"hello"}`; ft.Len() != 1 || ft.IndexString(0) != want {
+		t.Errorf("unexpected log %v (want %#v)", ft.log, want)
+	}
+}
+
+// Logs a message of each kind from each of its methods.
+type legacyElem struct{}
+
+func (test legacyElem) Before(t T) { t.Log("x"); t.Error("y") }
+func (test legacyElem) Test(t T) {
+	t.Log("l")
+	t.Logf("%s", "lf")
+	t.Error("e")
+	t.Errorf("%s", "ef")
+	t.Fatalf("%s", "ff")
+}
+func (test legacyElem) After(t T) { t.Fatal("z") }
+
+type legacyFormatTest struct {
+	verbose bool
+	msgfmt  string
+	log     []string
+}
+
+func (test legacyFormatTest) Test(t T) {
+	defer func(v bool, f string) { Verbose, MsgFmt = v, f }(Verbose, MsgFmt)
+	Verbose, MsgFmt = test.verbose, test.msgfmt
+	ft := new(fauxT)
+	func() {
+		defer func() { catchfailed(recover()) }()
+		testWith(ft, []legacyElem{{}})
+	}()
+	var log []string
+	for i := range ft.log {
+		log = append(log, ft.IndexString(i))
+	}
+	if len(log) != len(test.log) {
+		t.Fatalf("unexpected log %q", log)
+	}
+	for i := range log {
+		if log[i] != test.log[i] {
+			t.Errorf("line %d %q != %q", i, log[i], test.log[i])
+		}
+	}
+}
+
+// The output of the legacy layout, as it was before Formatters.
+var legacyFormatTests = []legacyFormatTest{
+	{false, "%s: %s", []string{
+		"table.legacyElem 0: before test: x",
+		"table.legacyElem 0: before test: y",
+		"table.legacyElem 0: l",
+		"table.legacyElem 0: lf",
+		"table.legacyElem 0: e",
+		"table.legacyElem 0: ef",
+		"table.legacyElem 0: ff",
+		"table.legacyElem 0: after test: z",
+	}},
+	{true, "%s: %s", []string{
+		"table.legacyElem 0: before test: x",
+		"error: table.legacyElem 0: table.legacyElem 0 error: before test: before test error: y",
+		"table.legacyElem 0: l",
+		"table.legacyElem 0: lf",
+		"error: table.legacyElem 0: table.legacyElem 0 error: e",
+		"error: table.legacyElem 0: ef",
+		"fatal: table.legacyElem 0: ff",
+		"fatal: table.legacyElem 0: table.legacyElem 0 fatal: after test: after test fatal: z",
+	}},
+	{false, "[%s] %s", []string{
+		"[table.legacyElem 0] [before test] x",
+		"[table.legacyElem 0] [before test] y",
+		"[table.legacyElem 0] l",
+		"[table.legacyElem 0] lf",
+		"[table.legacyElem 0] e",
+		"[table.legacyElem 0] ef",
+		"[table.legacyElem 0] ff",
+		"[table.legacyElem 0] [after test] z",
+	}},
+}
+
+func TestLegacyFormat(t *testing.T) {
+	for i, test := range legacyFormatTests {
+		elementTest(subT(sprintf("legacy format %d", i), t), test)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    guard.go
+ *  Description: Detection of global state changed by table elements.
+ */
+
+import (
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Fail elements which change global state: environment variables, the working
+// directory, the umask (on unix), the Verbose and MsgFmt globals of this
+// package, and the variables pointed to by globals (keyed by the names used
+// to report them). Changed state is logged and restored before the next
+// element is tested (see Parallel). Guard panics if globals holds something
+// other than a pointer.
+func Guard(globals map[string]interface{}) Option {
+	ptrs := map[string]reflect.Value{
+		"table.Verbose": reflect.ValueOf(&Verbose),
+		"table.MsgFmt":  reflect.ValueOf(&MsgFmt),
+	}
+	for name, g := range globals {
+		v := reflect.ValueOf(g)
+		if v.Kind() != reflect.Ptr || v.IsNil() {
+			panic(sprintf("table: Guard: %s is a %T, not a pointer", name, g))
+		}
+		ptrs[name] = v
+	}
+	return func(c *config) { c.guard = ptrs }
+}
+
+// A copy of global state.
+type globalState struct {
+	env     []string
+	cwd     string
+	umask   int // Negative where there is no umask.
+	globals map[string]reflect.Value
+}
+
+func saveGlobals(ptrs map[string]reflect.Value) *globalState {
+	s := &globalState{env: os.Environ(), umask: umask()}
+	s.cwd, _ = os.Getwd()
+	s.globals = make(map[string]reflect.Value)
+	for name, p := range ptrs {
+		v := reflect.New(p.Elem().Type()).Elem()
+		v.Set(p.Elem())
+		s.globals[name] = v
+	}
+	return s
+}
+
+// The state as formatted values, by name.
+func (s *globalState) values() map[string]string {
+	vals := make(map[string]string)
+	for _, kv := range s.env {
+		if i := strings.Index(kv, "="); i > 0 {
+			vals["$"+kv[:i]] = sprintf("%q", kv[i+1:])
+		}
+	}
+	vals["working directory"] = sprintf("%q", s.cwd)
+	if s.umask >= 0 {
+		vals["umask"] = sprintf("%#o", s.umask)
+	}
+	for name, v := range s.globals {
+		vals[name] = sprintf("%#v", v.Interface())
+	}
+	return vals
+}
+
+// Describe the changes from state s to state t, sorted by name.
+func (s *globalState) diff(t *globalState) (changes []string) {
+	before, after := s.values(), t.values()
+	for name, v := range before {
+		if w, ok := after[name]; !ok {
+			changes = append(changes, sprintf("%s: %s => unset", name, v))
+		} else if w != v {
+			changes = append(changes, sprintf("%s: %s => %s", name, v, w))
+		}
+	}
+	for name, w := range after {
+		if _, ok := before[name]; !ok {
+			changes = append(changes, sprintf("%s: unset => %s", name, w))
+		}
+	}
+	sort.Strings(changes)
+	return
+}
+
+func (s *globalState) restore(ptrs map[string]reflect.Value) {
+	os.Clearenv()
+	for _, kv := range s.env {
+		if i := strings.Index(kv, "="); i > 0 {
+			os.Setenv(kv[:i], kv[i+1:])
+		}
+	}
+	os.Chdir(s.cwd)
+	if s.umask >= 0 {
+		setUmask(s.umask)
+	}
+	for name, p := range ptrs {
+		p.Elem().Set(s.globals[name])
+	}
+}
+
+// Fail the element tested by t if global state has changed since before, and
+// restore it.
+func checkGuard(t *testingT, before *globalState) {
+	ptrs := t.cfg.guard
+	changes := before.diff(saveGlobals(ptrs))
+	if len(changes) == 0 {
+		return
+	}
+	before.restore(ptrs)
+	t.Errorf("changed global state (restored)\n\t%s", strings.Join(changes, "\n\t"))
+}
@@ -0,0 +1,14 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !unix
+
+package table
+
+/*  Filename:    guard_other.go
+ *  Description: Systems without a umask.
+ */
+
+func umask() int     { return -1 }
+func setUmask(m int) {}
@@ -0,0 +1,71 @@
+package table
+
+/*  Filename:    guard_test.go
+ *  Description: For testing guard.go
+ */
+
+import (
+	"os"
+	"testing"
+)
+
+var guarded = 1
+
+// Changes global state.
+type mutateElem func()
+
+func (test mutateElem) Test(t T) { test() }
+
+type guardTest struct {
+	elem mutateElem
+	log  []string
+}
+
+func (test guardTest) Test(t T) {
+	ft := fauxTest("guard", func(t T) {
+		testWith(t, []mutateElem{test.elem, func() {}}, Guard(map[string]interface{}{"guarded": &guarded}))
+	})
+	if got := sprint(ft.log); got != sprint(test.log) {
+		t.Errorf("unexpected log %v", got)
+	}
+	if ft.failed != (len(test.log) > 0) {
+		t.Errorf("failed %v", ft.failed)
+	}
+	if v, ok := os.LookupEnv("TABLE_GUARD"); ok {
+		t.Errorf("environment not restored: TABLE_GUARD=%q", v)
+	}
+	if guarded != 1 || Verbose {
+		t.Errorf("globals not restored: %v %v", guarded, Verbose)
+	}
+}
+
+var guardTests = []guardTest{
+	{func() {}, nil},
+	{func() { guarded = 2; Verbose = true }, []string{
+		"guard: table.mutateElem 0: changed global state (restored)\n\tguarded: 1 => 2\n\ttable.Verbose: false => true",
+	}},
+	{func() { os.Setenv("TABLE_GUARD", "x") }, []string{
+		"guard: table.mutateElem 0: changed global state (restored)\n\t$TABLE_GUARD: unset => \"x\"",
+	}},
+	{func() { os.Setenv("TABLE_GUARD", "x"); os.Unsetenv("TABLE_GUARD") }, nil},
+}
+
+func TestGuard(t *testing.T) {
+	for i, test := range guardTests {
+		elementTest(subT(sprintf("guard %d", i), t), test)
+	}
+}
+
+func TestGuardWorkingDirectory(t *testing.T) {
+	wd, _ := os.Getwd()
+	dir := t.TempDir()
+	ft := fauxTest("guard", func(t T) {
+		testWith(t, []mutateElem{func() { os.Chdir(dir) }}, Guard(nil))
+	})
+	if !ft.failed {
+		t.Error("changing directory did not fail")
+	}
+	if now, _ := os.Getwd(); now != wd {
+		t.Errorf("working directory not restored: %s", now)
+	}
+}
@@ -0,0 +1,24 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package table
+
+/*  Filename:    guard_unix.go
+ *  Description: The process umask.
+ */
+
+import (
+	"syscall"
+)
+
+// The umask can only be read by setting it.
+func umask() int {
+	m := syscall.Umask(0)
+	syscall.Umask(m)
+	return m
+}
+
+func setUmask(m int) { syscall.Umask(m) }
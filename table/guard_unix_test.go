@@ -0,0 +1,26 @@
+//go:build unix
+
+package table
+
+/*  Filename:    guard_unix_test.go
+ *  Description: For testing guard_unix.go
+ */
+
+import (
+	"syscall"
+	"testing"
+)
+
+func TestGuardUmask(t *testing.T) {
+	m := umask()
+	ft := fauxTest("guard", func(t T) {
+		testWith(t, []mutateElem{func() { syscall.Umask(m ^ 0o7) }}, Guard(nil))
+	})
+	want := []string{sprintf("guard: table.mutateElem 0: changed global state (restored)\n\tumask: %#o => %#o", m, m^0o7)}
+	if got := sprint(ft.log); got != sprint(want) {
+		t.Errorf("unexpected log %v", got)
+	}
+	if umask() != m {
+		t.Errorf("umask not restored: %#o", umask())
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    keys.go
+ *  Description: Ordering and naming of map table keys.
+ */
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Test the elements of map tables in the order given by less, which is
+// passed two keys of the map. Without KeyOrder, string keys are sorted
+// naturally (so "row 2" precedes "row 10"), numeric keys by value, and
+// structs and arrays by their formatted values. Maps with pointer or channel
+// keys need a KeyOrder.
+func KeyOrder(less func(a, b interface{}) bool) Option {
+	return func(c *config) { c.less = less }
+}
+
+// The keys of map v in the configured order.
+func sortedKeys(v reflect.Value, cfg *config) ([]reflect.Value, error) {
+	keys := v.MapKeys()
+	if cfg != nil && cfg.less != nil {
+		sort.SliceStable(keys, func(i, j int) bool {
+			return cfg.less(keys[i].Interface(), keys[j].Interface())
+		})
+		return keys, nil
+	}
+	for _, k := range keys {
+		if !orderedKey(k) {
+			return nil, errorf("map table keys of type %v have no order; use KeyOrder", k.Type())
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool { return compareKeys(keys[i], keys[j]) < 0 })
+	return keys, nil
+}
+
+// The dynamic value of interface keys.
+func concreteKey(k reflect.Value) reflect.Value {
+	for k.Kind() == reflect.Interface && !k.IsNil() {
+		k = k.Elem()
+	}
+	return k
+}
+
+// Reports whether keys like k can be sorted without KeyOrder.
+func orderedKey(k reflect.Value) bool {
+	switch concreteKey(k).Kind() {
+	case reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		return false
+	}
+	return true
+}
+
+func compareKeys(a, b reflect.Value) int {
+	a, b = concreteKey(a), concreteKey(b)
+	if a.Kind() == reflect.Interface || b.Kind() == reflect.Interface {
+		return compareBool(a.Kind() != reflect.Interface, b.Kind() != reflect.Interface)
+	}
+	if a.Type() != b.Type() {
+		return naturalCompare(a.Type().String(), b.Type().String())
+	}
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compareInt(a.Int(), b.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return compareUint(a.Uint(), b.Uint())
+	case reflect.Float32, reflect.Float64:
+		return compareFloat(a.Float(), b.Float())
+	case reflect.Bool:
+		return compareBool(a.Bool(), b.Bool())
+	case reflect.String:
+		return naturalCompare(a.String(), b.String())
+	}
+	return naturalCompare(sprintf("%+v", a.Interface()), sprintf("%+v", b.Interface()))
+}
+
+func compareInt(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareUint(a, b uint64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloat(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareBool(a, b bool) int {
+	switch {
+	case a == b:
+		return 0
+	case b:
+		return -1
+	}
+	return 1
+}
+
+func isDigit(c byte) bool { return '0' <= c && c <= '9' }
+
+// Compare strings, treating runs of digits as numbers. Strings equal as
+// numbers (e.g. "01" and "1") are compared byte by byte.
+func naturalCompare(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if !isDigit(a[i]) || !isDigit(b[j]) {
+			if a[i] != b[j] {
+				return compareInt(int64(a[i]), int64(b[j]))
+			}
+			i, j = i+1, j+1
+			continue
+		}
+		si, sj := i, j
+		for i < len(a) && isDigit(a[i]) {
+			i++
+		}
+		for j < len(b) && isDigit(b[j]) {
+			j++
+		}
+		x := strings.TrimLeft(a[si:i], "0")
+		y := strings.TrimLeft(b[sj:j], "0")
+		if c := compareInt(int64(len(x)), int64(len(y))); c != 0 {
+			return c
+		}
+		if c := strings.Compare(x, y); c != 0 {
+			return c
+		}
+	}
+	if c := compareInt(int64(len(a)-i), int64(len(b)-j)); c != 0 {
+		return c
+	}
+	return strings.Compare(a, b)
+}
+
+// The name of the element with key k, the i-th key in order. Keys which
+// can't be formatted stably (e.g. pointers) are named like slice elements.
+func keyName(k interface{}, i int) string {
+	v := concreteKey(reflect.ValueOf(k))
+	if !v.IsValid() {
+		return "<nil>"
+	}
+	if s, ok := v.Interface().(stringer); ok {
+		return s.String()
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Chan, reflect.UnsafePointer:
+		return sprintf("%v %d", v.Type(), i)
+	case reflect.Struct:
+		return sprintf("%+v", v.Interface())
+	}
+	return sprint(v.Interface())
+}
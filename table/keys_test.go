@@ -0,0 +1,119 @@
+package table
+
+/*  Filename:    keys_test.go
+ *  Description: For testing keys.go
+ */
+
+import (
+	"reflect"
+	"testing"
+)
+
+type naturalTest struct {
+	a, b string
+	cmp  int
+}
+
+func (test naturalTest) Test(t T) {
+	if c := naturalCompare(test.a, test.b); c != test.cmp {
+		t.Errorf("naturalCompare(%q, %q) => %d != %d", test.a, test.b, c, test.cmp)
+	}
+	if c := naturalCompare(test.b, test.a); c != -test.cmp {
+		t.Errorf("naturalCompare(%q, %q) => %d != %d", test.b, test.a, c, -test.cmp)
+	}
+}
+
+var naturalTests = []naturalTest{
+	{"a", "a", 0},
+	{"a", "b", -1},
+	{"row 2", "row 10", -1},
+	{"row 10", "row 10a", -1},
+	{"x01", "x1", -1},
+	{"x007y", "x7z", -1},
+	{"10", "9", 1},
+	{"", "0", -1},
+	{"a1b2", "a1b10", -1},
+}
+
+func TestNaturalCompare(t *testing.T) {
+	for i, test := range naturalTests {
+		elementTest(subT(sprintf("natural %d", i), t), test)
+	}
+}
+
+type point struct{ X, Y int }
+
+type sortedKeysTest struct {
+	table interface{}
+	less  func(a, b interface{}) bool
+	keys  []string
+	err   string
+}
+
+func (test sortedKeysTest) Test(t T) {
+	keys, err := sortedKeys(reflect.ValueOf(test.table), &config{less: test.less})
+	msg := ""
+	if err != nil {
+		msg = err.Error()
+	}
+	if msg != test.err {
+		t.Errorf("error %q != %q", msg, test.err)
+	}
+	var names []string
+	for i, k := range keys {
+		names = append(names, keyName(k.Interface(), i))
+	}
+	if sprint(names) != sprint(test.keys) {
+		t.Errorf("keys %q != %q", names, test.keys)
+	}
+}
+
+var one, two = new(int), new(int)
+
+var sortedKeysTests = []sortedKeysTest{
+	{map[string]int{"row 10": 0, "row 2": 0, "row 1": 0}, nil, []string{"row 1", "row 2", "row 10"}, ""},
+	{map[int]int{-1: 0, 10: 0, 2: 0}, nil, []string{"-1", "2", "10"}, ""},
+	{map[uint8]int{255: 0, 3: 0}, nil, []string{"3", "255"}, ""},
+	{map[float64]int{2.5: 0, -1: 0}, nil, []string{"-1", "2.5"}, ""},
+	{map[bool]int{true: 0, false: 0}, nil, []string{"false", "true"}, ""},
+	{map[point]int{{2, 1}: 0, {10, 0}: 0}, nil, []string{"{X:2 Y:1}", "{X:10 Y:0}"}, ""},
+	{map[interface{}]int{"b": 0, 1: 0, "a": 0, nil: 0}, nil, []string{"<nil>", "1", "a", "b"}, ""},
+	{map[string]int{"a": 0, "b": 0}, func(a, b interface{}) bool { return a.(string) > b.(string) },
+		[]string{"b", "a"}, ""},
+	{map[*int]int{one: 0}, nil, nil, "map table keys of type *int have no order; use KeyOrder"},
+	{map[*int]int{one: 1, two: 2}, func(a, b interface{}) bool { return a == one }, []string{"*int 0", "*int 1"}, ""},
+}
+
+func TestSortedKeys(t *testing.T) {
+	for i, test := range sortedKeysTests {
+		elementTest(subT(sprintf("sortedKeys %d", i), t), test)
+	}
+}
+
+func TestMapTable(t *testing.T) {
+	rep := new(recordReporter)
+	ft := fauxTest("map", func(t T) {
+		testWith(t, map[string]Element{"row 10": runElem(""), "row 9": runElem("bad"), "row 11": nil}, Report(rep))
+	})
+	var names []string
+	for _, res := range rep.results {
+		names = append(names, res.Name)
+	}
+	if sprint(names) != "[map: row 9 map: row 10 map: row 11]" {
+		t.Errorf("unexpected names %q", names)
+	}
+	want := []string{"map: row 9: bad", "map: row 11: nil table element"}
+	if got := sprint(ft.log); got != sprint(want) {
+		t.Errorf("unexpected log %v", got)
+	}
+}
+
+func TestMapTableNilPointer(t *testing.T) {
+	ft := fauxTest("map", func(t T) {
+		testWith(t, map[string]*metaTagElem{"a": {}, "b": nil})
+	})
+	want := []string{"map: b: nil table element"}
+	if got := sprint(ft.log); got != sprint(want) {
+		t.Errorf("unexpected log %v", got)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    leak.go
+ *  Description: Detection of goroutines leaked by table elements.
+ */
+
+import (
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Fail elements which leave goroutines running for longer than grace after
+// they finish (after their After method and cleanup functions return, and
+// their Context is canceled), testing them one at a time (see Parallel).
+// Goroutines started concurrently by other Go tests (see testing.T.Parallel)
+// may be mistaken for leaks.
+func Leaks(grace time.Duration) Option {
+	return func(c *config) { c.leaks, c.grace = true, grace }
+}
+
+// The stacks of all goroutines, by goroutine header (e.g. "goroutine 7").
+func goroutines() map[string]string {
+	buf := make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+	g := make(map[string]string)
+	for _, stack := range strings.Split(string(buf), "\n\n") {
+		id := stack
+		if i := strings.Index(stack, " ["); i >= 0 {
+			id = stack[:i]
+		}
+		g[id] = stack
+	}
+	return g
+}
+
+// The header of the calling goroutine's stack (e.g. "goroutine 7").
+func currentGoroutine() string {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	if i := strings.Index(string(buf), " ["); i >= 0 {
+		return string(buf[:i])
+	}
+	return string(buf)
+}
+
+// Goroutines running now which were not running before, sorted by header.
+func newGoroutines(before map[string]string) (leaked []string) {
+	for id, stack := range goroutines() {
+		if _, ok := before[id]; !ok {
+			leaked = append(leaked, stack)
+		}
+	}
+	sort.Strings(leaked)
+	return
+}
+
+// Fail the element tested by t if goroutines not in before are still running
+// after grace.
+func checkLeaks(t *testingT, before map[string]string, grace time.Duration) {
+	t.state.cancel()
+	deadline := time.Now().Add(grace)
+	for {
+		leaked := newGoroutines(before)
+		if len(leaked) == 0 {
+			return
+		}
+		if time.Now().After(deadline) {
+			for _, stack := range leaked {
+				t.Errorf("leaked %s", stack)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
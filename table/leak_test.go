@@ -0,0 +1,63 @@
+package table
+
+/*  Filename:    leak_test.go
+ *  Description: For testing leak.go
+ */
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Blocks a goroutine until the channel is closed.
+type leakElem chan bool
+
+func (test leakElem) Test(t T) { go leakyWait(test) }
+
+func leakyWait(c chan bool) { <-c }
+
+// Starts a goroutine which exits after a short time.
+type shortElem struct{}
+
+func (test shortElem) Test(t T) { go time.Sleep(5 * time.Millisecond) }
+
+// Starts a goroutine which exits when the element's context is canceled.
+type contextLeakElem struct{}
+
+func (test contextLeakElem) Test(t T) { go func() { <-Context(t).Done() }() }
+
+// Starts a goroutine stopped by a cleanup function.
+type cleanupLeakElem struct{}
+
+func (test cleanupLeakElem) Test(t T) {
+	c := make(chan bool)
+	go leakyWait(c)
+	Cleanup(t, func() { close(c) })
+}
+
+func TestLeaks(t *testing.T) {
+	c := make(chan bool)
+	defer close(c)
+	ft := fauxTest("leaks", func(t T) {
+		testWith(t, []Element{shortElem{}, leakElem(c), contextLeakElem{}, cleanupLeakElem{}},
+			Leaks(100*time.Millisecond), Parallel(4))
+	})
+	if !ft.failed {
+		t.Error("leaking element did not fail")
+	}
+	if len(ft.log) != 1 {
+		t.Fatalf("unexpected log %v", ft.log)
+	}
+	m := sprint(ft.log[0].v)
+	if !strings.HasPrefix(m, "leaks: table.leakElem 1: leaked goroutine ") || !strings.Contains(m, "table.leakyWait(") {
+		t.Errorf("unexpected message %q", m)
+	}
+}
+
+func TestNoLeaks(t *testing.T) {
+	ft := fauxTest("leaks", func(t T) { testWith(t, []runElem{"", ""}, Leaks(0)) })
+	if ft.failed || len(ft.log) != 0 {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+}
@@ -0,0 +1,313 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    location.go
+ *  Description: Source locations of table elements.
+ */
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// A table element along with the place it was declared, and possibly a name.
+type row struct {
+	Element
+	loc  string
+	name string
+}
+
+// Wrap an element, recording the file and line of the call to Row as its
+// location. Use Row for elements of tables which are not composite literals
+// (e.g. tables built in a loop). Elements of literal tables are located
+// automatically.
+func Row(elem Element) Element {
+	_, file, line, ok := runtime.Caller(1)
+	if !ok {
+		return elem
+	}
+	return row{elem, fileLine(file, line), ""}
+}
+
+// Remove any Row wrapper from an element and return its location and name.
+func unwrapRow(elem interface{}) (interface{}, string, string) {
+	if r, ok := elem.(row); ok {
+		return r.Element, r.loc, r.name
+	}
+	return elem, "", ""
+}
+
+func fileLine(file string, line int) string { return sprintf("%s:%d", filepath.Base(file), line) }
+
+// The locations of elements in a composite literal table.
+type locations struct {
+	index map[int]string    // Slice elements by index.
+	key   map[string]string // Map elements by (formatted) key.
+}
+
+func (locs *locations) atIndex(i int) string {
+	if locs == nil {
+		return ""
+	}
+	return locs.index[i]
+}
+
+func (locs *locations) atKey(k interface{}) string {
+	if locs == nil {
+		return ""
+	}
+	return locs.key[sprint(k)]
+}
+
+// Locate the elements of a table passed as argument arg (counting from zero)
+// of the call skip frames above the caller of CallSite. Test does this itself;
+// packages wrapping Test use CallSite to locate the elements in their
+// caller's table instead. For example, a function F(t, h, rows) calling Test
+// would pass CallSite(1, 2).
+func CallSite(skip, arg int) Option {
+	_, file, line, ok := runtime.Caller(skip + 1)
+	return func(c *config) {
+		if ok {
+			c.file, c.line, c.arg = file, line, arg
+		}
+	}
+}
+
+// Record the location of the caller of the function calling callSite.
+func callSite(skip int) Option { return CallSite(skip+1, 1) }
+
+var parsed = struct {
+	sync.Mutex
+	fset  *token.FileSet
+	files map[string]*ast.File
+}{fset: token.NewFileSet(), files: make(map[string]*ast.File)}
+
+// Parse a source file once. Returns nil if the file can't be parsed.
+func parseFile(file string) *ast.File {
+	parsed.Lock()
+	defer parsed.Unlock()
+	f, ok := parsed.files[file]
+	if !ok {
+		f, _ = parser.ParseFile(parsed.fset, file, nil, parser.SkipObjectResolution)
+		parsed.files[file] = f
+	}
+	return f
+}
+
+func position(p token.Pos) token.Position { return parsed.fset.Position(p) }
+
+// Find the locations of the elements of the table passed as argument arg to
+// the call of a function named fn at file:line (or another call, when there is
+// no such call on the line). The table must be a composite literal, or an
+// identifier last assigned one (see findDeclaration). Returns nil when the
+// elements can't be located.
+func findLocations(file string, line, arg int, fn string) *locations {
+	if file == "" {
+		return nil
+	}
+	f := parseFile(file)
+	if f == nil {
+		return nil
+	}
+	// Prefer calls to fn when several calls share the line.
+	var call *ast.CallExpr
+	ast.Inspect(f, func(n ast.Node) bool {
+		c, ok := n.(*ast.CallExpr)
+		if !ok || len(c.Args) <= arg {
+			return true
+		}
+		if position(c.Pos()).Line != line && position(c.Lparen).Line != line {
+			return true
+		}
+		if call == nil || funcName(c) == fn && funcName(call) != fn {
+			call = c
+		}
+		return true
+	})
+	if call == nil {
+		return nil
+	}
+	var lit *ast.CompositeLit
+	switch arg := call.Args[arg].(type) {
+	case *ast.CompositeLit:
+		lit = arg
+	case *ast.Ident:
+		lit = findDeclaration(f, arg.Name, call.Pos())
+	}
+	if lit == nil {
+		return nil
+	}
+	return literalLocations(file, lit)
+}
+
+func funcName(call *ast.CallExpr) string {
+	switch fn := call.Fun.(type) {
+	case *ast.Ident:
+		return fn.Name
+	case *ast.SelectorExpr:
+		return fn.Sel.Name
+	}
+	return ""
+}
+
+// Find the composite literal assigned to name by the last assignment before
+// pos in the function containing pos, or by the declaration of a package level
+// variable when the function doesn't declare name. Returns nil when name was
+// last assigned something else (e.g. the result of a call).
+func findDeclaration(f *ast.File, name string, pos token.Pos) *ast.CompositeLit {
+	var fn ast.Node // The outermost function containing pos.
+	ast.Inspect(f, func(n ast.Node) bool {
+		switch n.(type) {
+		case *ast.FuncDecl, *ast.FuncLit:
+			if fn == nil && n.Pos() <= pos && pos < n.End() {
+				fn = n
+			}
+		}
+		return fn == nil
+	})
+	if fn != nil {
+		if val, ok := lastAssignment(fn, name, pos); ok {
+			lit, _ := val.(*ast.CompositeLit)
+			return lit
+		}
+	}
+	for _, d := range f.Decls {
+		d, ok := d.(*ast.GenDecl)
+		if !ok || d.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range d.Specs {
+			spec := spec.(*ast.ValueSpec)
+			for i, id := range spec.Names {
+				if id.Name == name && i < len(spec.Values) {
+					lit, _ := spec.Values[i].(*ast.CompositeLit)
+					return lit
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// The value (nil when unknown) of the last assignment to name before pos in
+// the function fn. Declarations count only when pos is in their scope, while assignments
+// to variables declared elsewhere always count. Reports false when name is
+// not assigned.
+func lastAssignment(fn ast.Node, name string, pos token.Pos) (val ast.Expr, ok bool) {
+	var at token.Pos
+	assign := func(id ast.Expr, v ast.Expr, p token.Pos, scope ast.Node) {
+		ident, isIdent := id.(*ast.Ident)
+		if !isIdent || ident.Name != name || p >= pos || ok && p < at {
+			return
+		}
+		if scope != nil && (pos < scope.Pos() || scope.End() <= pos) {
+			return
+		}
+		val, ok, at = v, true, p
+	}
+	values := func(ids, vals []ast.Expr, p token.Pos, scope ast.Node) {
+		for i, id := range ids {
+			var v ast.Expr
+			if len(vals) == len(ids) {
+				v = vals[i]
+			}
+			assign(id, v, p, scope)
+		}
+	}
+	fields := func(list *ast.FieldList, scope ast.Node) {
+		if list == nil {
+			return
+		}
+		for _, field := range list.List {
+			for _, id := range field.Names {
+				assign(id, nil, id.Pos(), scope)
+			}
+		}
+	}
+	scopes := []ast.Node{fn} // The innermost scope of each node being inspected.
+	ast.Inspect(fn, func(n ast.Node) bool {
+		if n == nil {
+			scopes = scopes[:len(scopes)-1]
+			return false
+		}
+		scope := scopes[len(scopes)-1]
+		switch n := n.(type) {
+		case *ast.FuncDecl:
+			fields(n.Recv, n)
+			fields(n.Type.Params, n)
+			fields(n.Type.Results, n)
+		case *ast.FuncLit:
+			fields(n.Type.Params, n)
+			fields(n.Type.Results, n)
+		case *ast.AssignStmt:
+			switch {
+			case n.Tok == token.DEFINE:
+				values(n.Lhs, n.Rhs, n.Pos(), scope)
+			case n.Tok == token.ASSIGN:
+				values(n.Lhs, n.Rhs, n.Pos(), nil)
+			default:
+				values(n.Lhs, nil, n.Pos(), nil)
+			}
+		case *ast.ValueSpec:
+			var ids []ast.Expr
+			for _, id := range n.Names {
+				ids = append(ids, id)
+			}
+			values(ids, n.Values, n.Pos(), scope)
+		case *ast.RangeStmt:
+			scope = nil
+			if n.Tok == token.DEFINE {
+				scope = n
+			}
+			values([]ast.Expr{n.Key, n.Value}, nil, n.Pos(), scope)
+		}
+		switch n.(type) {
+		case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause, *ast.IfStmt,
+			*ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt:
+			scopes = append(scopes, n)
+		default:
+			scopes = append(scopes, scopes[len(scopes)-1])
+		}
+		return true
+	})
+	return val, ok
+}
+
+func literalLocations(file string, lit *ast.CompositeLit) *locations {
+	locs := &locations{make(map[int]string), make(map[string]string)}
+	i := 0
+	for _, elt := range lit.Elts {
+		loc := fileLine(file, position(elt.Pos()).Line)
+		kv, ok := elt.(*ast.KeyValueExpr)
+		if !ok {
+			locs.index[i] = loc
+			i++
+			continue
+		}
+		key, ok := kv.Key.(*ast.BasicLit)
+		if !ok {
+			continue
+		}
+		switch key.Kind {
+		case token.INT:
+			if n, err := strconv.Atoi(key.Value); err == nil {
+				locs.index[n] = loc
+				i = n + 1
+			}
+			locs.key[key.Value] = loc
+		case token.STRING:
+			if s, err := strconv.Unquote(key.Value); err == nil {
+				locs.key[s] = loc
+			}
+		}
+	}
+	return locs
+}
@@ -0,0 +1,92 @@
+package table
+
+/*  Filename:    location_test.go
+ *  Description: For testing location.go
+ */
+
+import (
+	"testing"
+)
+
+type locationElem string
+
+func (test locationElem) Test(t T) {
+	if test != "" {
+		t.Error(string(test))
+	}
+}
+
+var locationTable = []locationElem{
+	"",
+	"declared",
+}
+
+var locationMap = map[string]locationElem{
+	"a": "",
+	"b": "keyed",
+}
+
+// Wraps testWith like a helper package would.
+func locationWrapper(t T, x int, table []locationElem) { testWith(t, table, CallSite(1, 2)) }
+
+var locationTests = []metaTestSimple{
+	{"declared table", func(t T) { testWith(t, locationTable, callSite(0)) },
+		[]string{`^location_test.go:21: simple meta-test: table.locationElem 1: declared$`}},
+	{"literal table", func(t T) { testWith(t, []locationElem{"", "literal"}, callSite(0)) },
+		[]string{`^location_test.go:35: simple meta-test: table.locationElem 1: literal$`}},
+	{"keyed table", func(t T) { testWith(t, locationMap, callSite(0)) },
+		[]string{`^location_test.go:26: simple meta-test: b: keyed$`}},
+	{"row", func(t T) { testWith(t, []Element{Row(locationElem("row"))}) },
+		[]string{`^location_test.go:39: simple meta-test: table.locationElem 0: row$`}},
+	{"wrapped", func(t T) { locationWrapper(t, 0, []locationElem{"wrapped"}) },
+		[]string{`^location_test.go:41: simple meta-test: table.locationElem 0: wrapped$`}},
+	{"unknown", func(t T) { testWith(t, []locationElem{"unknown"}) },
+		[]string{`^simple meta-test: table.locationElem 0: unknown$`}},
+}
+
+func TestLocations(t *testing.T) {
+	for i, test := range locationTests {
+		elementTest(subT(sprintf("location %d", i), t), test)
+	}
+}
+
+// Declares a table named tests, which other functions must not be located by.
+func locationOther(t T) {
+	tests := []locationElem{"", "other"}
+	testWith(t, tests, callSite(0))
+}
+
+func locationRows() []locationElem { return []locationElem{"", "built"} }
+
+// A parameter shadowing locationTable.
+func locationParam(t T, locationTable []locationElem) { testWith(t, locationTable, callSite(0)) }
+
+var locationScopeTests = []metaTestSimple{
+	{"other function", locationOther,
+		[]string{`^location_test.go:55: simple meta-test: table.locationElem 1: other$`}},
+	{"built table", func(t T) {
+		tests := locationRows()
+		testWith(t, tests, callSite(0))
+	}, []string{`^simple meta-test: table.locationElem 1: built$`}},
+	{"appended table", func(t T) {
+		tests := []locationElem{""}
+		tests = append(tests, "appended")
+		testWith(t, tests, callSite(0))
+	}, []string{`^simple meta-test: table.locationElem 1: appended$`}},
+	{"inner scope", func(t T) {
+		tests := []locationElem{"", "outer"}
+		if true {
+			tests := []locationElem{"", "inner"}
+			_ = tests
+		}
+		testWith(t, tests, callSite(0))
+	}, []string{`^location_test.go:77: simple meta-test: table.locationElem 1: outer$`}},
+	{"parameter", func(t T) { locationParam(t, []locationElem{"", "param"}) },
+		[]string{`^simple meta-test: table.locationElem 1: param$`}},
+}
+
+func TestLocationScope(t *testing.T) {
+	for i, test := range locationScopeTests {
+		elementTest(subT(sprintf("location scope %d", i), t), test)
+	}
+}
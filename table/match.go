@@ -0,0 +1,93 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    match.go
+ *  Description: Matchers for output produced by elements.
+ */
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+)
+
+var update = flag.Bool("table.update", false, "rewrite golden, snapshot and baseline files with the output of tests")
+
+// A Matcher checks output produced by an element (e.g. a response body),
+// returning an error describing any mismatch.
+type Matcher interface {
+	Match(got []byte) error
+}
+
+// An adapter allowing ordinary functions to be used as Matchers.
+type MatcherFunc func([]byte) error
+
+func (fn MatcherFunc) Match(got []byte) error { return fn(got) }
+
+// Match output identical to want.
+func Exact(want string) Matcher {
+	return MatcherFunc(func(got []byte) error {
+		if string(got) != want {
+			return errorf("%q, want %q", got, want)
+		}
+		return nil
+	})
+}
+
+// Match JSON output equal to want, ignoring formatting and the order of object
+// keys.
+func JSONEqual(want string) Matcher {
+	return MatcherFunc(func(got []byte) error {
+		var g, w interface{}
+		if err := json.Unmarshal([]byte(want), &w); err != nil {
+			return errorf("invalid expected JSON %q: %v", want, err)
+		}
+		if err := json.Unmarshal(got, &g); err != nil {
+			return errorf("invalid JSON %q: %v", got, err)
+		}
+		if !reflect.DeepEqual(g, w) {
+			return errorf("%s, want %s", bytes.TrimSpace(got), want)
+		}
+		return nil
+	})
+}
+
+// Match output containing a match of the regular expression pattern. Panics
+// if pattern is invalid.
+func Regexp(pattern string) Matcher {
+	r := regexp.MustCompile(pattern)
+	return MatcherFunc(func(got []byte) error {
+		if !r.Match(got) {
+			return errorf("%q doesn't match %v", got, r)
+		}
+		return nil
+	})
+}
+
+// Match output identical to the contents of the file at path. When tests are
+// run with the -table.update flag the file is rewritten instead.
+func Golden(path string) Matcher {
+	return MatcherFunc(func(got []byte) error {
+		if *update {
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			return os.WriteFile(path, got, 0644)
+		}
+		want, err := os.ReadFile(path)
+		if err != nil {
+			return errorf("%v (run with -table.update to create it)", err)
+		}
+		if !bytes.Equal(got, want) {
+			return errorf("%q, want %q (golden file %s)", got, want, path)
+		}
+		return nil
+	})
+}
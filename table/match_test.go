@@ -0,0 +1,62 @@
+package table
+
+/*  Filename:    match_test.go
+ *  Description: For testing match.go
+ */
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+type matchTest struct {
+	m   Matcher
+	got string
+	err string // Pattern matching the error; empty when the output matches.
+}
+
+func (test matchTest) Test(t T) {
+	err := test.m.Match([]byte(test.got))
+	switch {
+	case err == nil && test.err == "":
+	case err == nil:
+		t.Errorf("%q matched unexpectedly", test.got)
+	case test.err == "":
+		t.Errorf("unexpected error: %v", err)
+	case !regexp.MustCompile(test.err).MatchString(err.Error()):
+		t.Errorf("unexpected error (not %s): %v", test.err, err)
+	}
+}
+
+var matchTests = []matchTest{
+	{Exact("abc"), "abc", ""},
+	{Exact("abc"), "abcd", `"abcd", want "abc"`},
+	{JSONEqual(`{"a": 1, "b": [true]}`), `{"b":[true],"a":1}` + "\n", ""},
+	{JSONEqual(`{"a": 1}`), `{"a":2}`, `\{"a":2\}, want \{"a": 1\}`},
+	{JSONEqual(`{"a": 1}`), `{"a":`, `invalid JSON`},
+	{Regexp(`^b+$`), "bbb", ""},
+	{Regexp(`^b+$`), "abc", `doesn't match`},
+	{Golden("testdata/match.golden"), "golden\n", ""},
+	{Golden("testdata/match.golden"), "tarnished\n", `golden file testdata/match.golden`},
+	{Golden("testdata/missing.golden"), "", `-table.update`},
+}
+
+func TestMatch(t *testing.T) {
+	for i, test := range matchTests {
+		elementTest(subT(sprintf("match %d", i), t), test)
+	}
+}
+
+func TestGoldenUpdate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "x.golden")
+	*update = true
+	defer func() { *update = false }()
+	if err := Golden(path).Match([]byte("new")); err != nil {
+		t.Fatal(err)
+	}
+	if p, err := os.ReadFile(path); err != nil || string(p) != "new" {
+		t.Errorf("golden file not updated: %q %v", p, err)
+	}
+}
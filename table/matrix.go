@@ -0,0 +1,156 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    matrix.go
+ *  Description: Tables of every combination of parameters.
+ */
+
+import (
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// A named list of parameter values; one dimension of a Matrix.
+type Dimension struct {
+	Name   string
+	Values interface{} // A slice of values.
+}
+
+func Dim(name string, values interface{}) Dimension { return Dimension{name, values} }
+
+// A table of elements for every combination of the values of its dimensions.
+// Create one with Matrix, and give it a constructor with Elements.
+type MatrixTable struct {
+	dims    []Dimension
+	exclude []reflect.Value
+	fn      reflect.Value
+	loc     string
+}
+
+// The cartesian product of dims. A MatrixTable can be passed to Test as a
+// table, or returned by the Table method of an ElementTable. For example,
+//
+//	table.Test(t, table.Matrix(
+//		table.Dim("encoding", []string{"utf8", "latin1"}),
+//		table.Dim("size", []int{1, 512}),
+//	).Exclude(func(enc string, size int) bool {
+//		return enc == "latin1" && size == 1
+//	}).Elements(func(enc string, size int) table.Element {
+//		return codecTest{enc, size}
+//	}))
+//
+// tests three elements, named "encoding=utf8 size=1", "encoding=utf8 size=512"
+// and "encoding=latin1 size=512". The last dimension varies fastest. Elements
+// are located at the call to Matrix.
+func Matrix(dims ...Dimension) *MatrixTable {
+	m := &MatrixTable{dims: dims}
+	if _, file, line, ok := runtime.Caller(1); ok {
+		m.loc = fileLine(file, line)
+	}
+	return m
+}
+
+// Check that fn takes a value of each dimension, and returns one value.
+func (m *MatrixTable) checkFunc(method string, fn interface{}) reflect.Value {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func {
+		panic(sprintf("table: Matrix.%s: %T is not a function", method, fn))
+	}
+	typ := v.Type()
+	if typ.NumIn() != len(m.dims) || typ.NumOut() != 1 {
+		panic(sprintf("table: Matrix.%s: %v does not take %d arguments and return one value",
+			method, typ, len(m.dims)))
+	}
+	for i, d := range m.dims {
+		vals := reflect.ValueOf(d.Values)
+		if vals.Kind() != reflect.Slice {
+			panic(sprintf("table: Matrix: dimension %s values %T are not a slice", d.Name, d.Values))
+		}
+		if !vals.Type().Elem().AssignableTo(typ.In(i)) {
+			panic(sprintf("table: Matrix.%s: dimension %s values %v can't be argument %d of %v",
+				method, d.Name, vals.Type(), i, typ))
+		}
+	}
+	return v
+}
+
+// Leave out combinations for which pred returns true. The predicate takes a
+// value of each dimension, in order, and returns a bool. Exclude may be
+// called more than once.
+func (m *MatrixTable) Exclude(pred interface{}) *MatrixTable {
+	v := m.checkFunc("Exclude", pred)
+	if v.Type().Out(0).Kind() != reflect.Bool {
+		panic(sprintf("table: Matrix.Exclude: %v does not return a bool", v.Type()))
+	}
+	m.exclude = append(m.exclude, v)
+	return m
+}
+
+// Construct elements with fn, which takes a value of each dimension, in
+// order, and returns an Element.
+func (m *MatrixTable) Elements(fn interface{}) *MatrixTable {
+	v := m.checkFunc("Elements", fn)
+	if !v.Type().Out(0).Implements(elementType) {
+		panic(sprintf("table: Matrix.Elements: %v does not return an Element", v.Type()))
+	}
+	m.fn = v
+	return m
+}
+
+var elementType = reflect.TypeOf((*Element)(nil)).Elem()
+
+// The elements of the matrix, in a slice.
+func (m *MatrixTable) Table() interface{} {
+	if !m.fn.IsValid() {
+		panic("table: Matrix has no Elements function")
+	}
+	var rows []interface{}
+	m.each(func(args []reflect.Value) {
+		for _, pred := range m.exclude {
+			if pred.Call(args)[0].Bool() {
+				return
+			}
+		}
+		var names []string
+		for i, d := range m.dims {
+			names = append(names, sprintf("%s=%v", d.Name, args[i].Interface()))
+		}
+		name := strings.Join(names, " ")
+		elem, _ := m.fn.Call(args)[0].Interface().(Element)
+		rows = append(rows, row{elem, m.loc, name})
+	})
+	return rows
+}
+
+// Call fn with each combination of values.
+func (m *MatrixTable) each(fn func(args []reflect.Value)) {
+	vals := make([]reflect.Value, len(m.dims))
+	for i, d := range m.dims {
+		vals[i] = reflect.ValueOf(d.Values)
+		if vals[i].Len() == 0 {
+			return
+		}
+	}
+	idx := make([]int, len(m.dims))
+	for {
+		args := make([]reflect.Value, len(m.dims))
+		for i := range args {
+			args[i] = vals[i].Index(idx[i])
+		}
+		fn(args)
+		i := len(idx) - 1
+		for ; i >= 0; i-- {
+			if idx[i]++; idx[i] < vals[i].Len() {
+				break
+			}
+			idx[i] = 0
+		}
+		if i < 0 {
+			return
+		}
+	}
+}
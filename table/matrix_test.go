@@ -0,0 +1,104 @@
+package table
+
+/*  Filename:    matrix_test.go
+ *  Description: For testing matrix.go
+ */
+
+import (
+	"strings"
+	"testing"
+)
+
+// Fails for odd sizes.
+type matrixElem struct {
+	enc  string
+	size int
+}
+
+func (test matrixElem) Test(t T) {
+	if test.size%2 == 1 {
+		t.Errorf("odd size %d", test.size)
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	rep := new(recordReporter)
+	ft := fauxTest("matrix", func(t T) {
+		testWith(t, Matrix(
+			Dim("encoding", []string{"utf8", "latin1"}),
+			Dim("size", []int{1, 2, 512}),
+		).Exclude(func(enc string, size int) bool {
+			return enc == "latin1" && size == 1
+		}).Exclude(func(enc string, size int) bool {
+			return size == 2
+		}).Elements(func(enc string, size int) Element {
+			return matrixElem{enc, size}
+		}), Report(rep))
+	})
+	var names []string
+	for _, res := range rep.results {
+		names = append(names, res.Name)
+	}
+	want := []string{
+		"matrix: encoding=utf8 size=1",
+		"matrix: encoding=utf8 size=512",
+		"matrix: encoding=latin1 size=512",
+	}
+	if sprint(names) != sprint(want) {
+		t.Errorf("unexpected names %q", names)
+	}
+	if len(ft.log) != 1 || !ft.logLineLike(0, `^matrix_test.go:\d+: matrix: encoding=utf8 size=1: odd size 1$`) {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+}
+
+// A nested matrix, for each encoding.
+type encodingElem string
+
+func (test encodingElem) Table() interface{} {
+	return Matrix(Dim("size", []int{2, 4})).Elements(func(size int) Element {
+		return matrixElem{string(test), size}
+	})
+}
+
+func TestMatrixNested(t *testing.T) {
+	rep := new(recordReporter)
+	fauxTest("matrix", func(t T) {
+		testWith(t, []encodingElem{"utf8"}, Report(rep))
+	})
+	if len(rep.results) != 2 || rep.results[1].Name != "matrix: table.encodingElem 0/size=4" {
+		t.Errorf("unexpected results %#v", rep.results)
+	}
+}
+
+type matrixPanicTest struct {
+	build func() interface{}
+	panic string
+}
+
+func (test matrixPanicTest) Test(t T) {
+	defer func() {
+		if e := recover(); !strings.Contains(sprint(e), test.panic) {
+			t.Errorf("unexpected panic %v", e)
+		}
+	}()
+	test.build()
+	t.Errorf("no panic")
+}
+
+var matrixPanicTests = []matrixPanicTest{
+	{func() interface{} { return Matrix(Dim("x", []int{1})).Table() }, "no Elements function"},
+	{func() interface{} { return Matrix(Dim("x", []int{1})).Elements(3) }, "int is not a function"},
+	{func() interface{} { return Matrix(Dim("x", []int{1})).Elements(func() Element { return nil }) }, "does not take 1 arguments"},
+	{func() interface{} { return Matrix(Dim("x", 1)).Elements(func(int) Element { return nil }) }, "values int are not a slice"},
+	{func() interface{} { return Matrix(Dim("x", []int{1})).Elements(func(string) Element { return nil }) }, "values []int can't be argument 0"},
+	{func() interface{} { return Matrix(Dim("x", []int{1})).Exclude(func(int) int { return 0 }) }, "does not return a bool"},
+	{func() interface{} { return Matrix(Dim("x", []int{1})).Elements(func(int) int { return 0 }) }, "does not return an Element"},
+	{func() interface{} { return Matrix(Dim("x", []int{1})).Elements(func(int) interface{} { return nil }) }, "does not return an Element"},
+}
+
+func TestMatrixPanics(t *testing.T) {
+	for i, test := range matrixPanicTests {
+		elementTest(subT(sprintf("matrix panic %d", i), t), test)
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    meta.go
+ *  Description: Descriptive metadata of table elements.
+ */
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Describes an element for the people maintaining it. Metadata is logged when
+// an element fails and included in structured reports.
+type Meta struct {
+	Description string   `json:"description,omitempty"` // What the element checks.
+	Issues      []string `json:"issues,omitempty"`      // Related issue IDs or links.
+	Owner       string   `json:"owner,omitempty"`       // Who to ask about the element.
+}
+
+func (m Meta) isZero() bool { return m.Description == "" && len(m.Issues) == 0 && m.Owner == "" }
+
+func (m Meta) String() string {
+	var parts []string
+	if m.Description != "" {
+		parts = append(parts, m.Description)
+	}
+	if len(m.Issues) > 0 {
+		parts = append(parts, "issues: "+strings.Join(m.Issues, ", "))
+	}
+	if m.Owner != "" {
+		parts = append(parts, "owner: "+m.Owner)
+	}
+	return strings.Join(parts, "; ")
+}
+
+// An element describing itself. Instead of implementing ElementMeta, struct
+// elements may tag string fields `table:"description"`, `table:"owner"` and
+// `table:"issue"` (which may also tag a []string field).
+type ElementMeta interface {
+	Element     // ElementMeta is an Element.
+	Meta() Meta // Describes the element.
+}
+
+// Get the metadata of an element from its Meta method or its struct tags.
+func elementMeta(elem interface{}) (m Meta) {
+	if e, ok := elem.(ElementMeta); ok {
+		return e.Meta()
+	}
+	v := reflect.ValueOf(elem)
+	for v.Kind() == reflect.Ptr && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return
+	}
+	typ := v.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		f := v.Field(i)
+		var vals []string
+		switch {
+		case f.Kind() == reflect.String:
+			vals = []string{f.String()}
+		case f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.String:
+			for j := 0; j < f.Len(); j++ {
+				vals = append(vals, f.Index(j).String())
+			}
+		default:
+			continue
+		}
+		switch typ.Field(i).Tag.Get("table") {
+		case "description":
+			m.Description = strings.Join(vals, " ")
+		case "owner":
+			m.Owner = strings.Join(vals, ", ")
+		case "issue":
+			for _, issue := range vals {
+				if issue != "" {
+					m.Issues = append(m.Issues, issue)
+				}
+			}
+		}
+	}
+	return
+}
+
+// Log the metadata of a failed element.
+func logMeta(t *testingT, m Meta) {
+	if !m.isZero() && t.Failed() {
+		t.Logf("about: %v", m)
+	}
+}
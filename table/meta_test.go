@@ -0,0 +1,86 @@
+package table
+
+/*  Filename:    meta_test.go
+ *  Description: For testing meta.go
+ */
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type metaMethodElem struct{ fail bool }
+
+func (test metaMethodElem) Test(t T) {
+	if test.fail {
+		t.Error("failed")
+	}
+}
+func (test metaMethodElem) Meta() Meta {
+	return Meta{"checks the method", []string{"#1"}, "alice"}
+}
+
+type metaTagElem struct {
+	fail   bool
+	desc   string   `table:"description"`
+	issues []string `table:"issue"`
+	owner  string   `table:"owner"`
+	other  string
+}
+
+func (test metaTagElem) Test(t T) {
+	if test.fail {
+		t.Error("failed")
+	}
+}
+
+type elementMetaTest struct {
+	elem interface{}
+	meta Meta
+}
+
+func (test elementMetaTest) Test(t T) {
+	if m := elementMeta(test.elem); !reflect.DeepEqual(m, test.meta) {
+		t.Errorf("elementMeta(%#v) => %#v != %#v", test.elem, m, test.meta)
+	}
+}
+
+var elementMetaTests = []elementMetaTest{
+	{metaMethodElem{}, Meta{"checks the method", []string{"#1"}, "alice"}},
+	{metaTagElem{false, "checks tags", []string{"#2", "", "#3"}, "bob", "x"}, Meta{"checks tags", []string{"#2", "#3"}, "bob"}},
+	{&metaTagElem{owner: "carol"}, Meta{Owner: "carol"}},
+	{runElem("no meta"), Meta{}},
+	{(*metaTagElem)(nil), Meta{}},
+}
+
+func TestElementMeta(t *testing.T) {
+	for i, test := range elementMetaTests {
+		elementTest(subT(sprintf("elementMeta %d", i), t), test)
+	}
+}
+
+func TestMetaFailureOutput(t *testing.T) {
+	rep := new(recordReporter)
+	junit := new(bytes.Buffer)
+	ft := fauxTest("meta", func(t T) {
+		testWith(t, []Element{metaMethodElem{true}, metaMethodElem{false}, metaTagElem{fail: true, owner: "bob"}},
+			Report(rep), Report(JUnitReporter(junit)))
+	})
+	want := []string{
+		"meta: table.metaMethodElem 0: failed",
+		"meta: table.metaMethodElem 0: about: checks the method; issues: #1; owner: alice",
+		"meta: table.metaTagElem 2: failed",
+		"meta: table.metaTagElem 2: about: owner: bob",
+	}
+	if got := sprint(ft.log); got != sprint(want) {
+		t.Errorf("unexpected log %v", got)
+	}
+	if len(rep.results) != 3 || rep.results[1].Meta == nil || rep.results[1].Meta.Owner != "alice" {
+		t.Errorf("unexpected results %v", rep.results)
+	}
+	if !strings.Contains(junit.String(), `<property name="issue" value="#1"></property>`) {
+		t.Errorf("missing junit property:\n%s", junit)
+	}
+}
@@ -7,7 +7,7 @@ package table
 /*  Filename:    errors.go
  *  Author:      Bryan Matsuo <bmatsuo@soe.ucsc.edu>
  *  Created:     Fri Dec  9 08:52:30 PST 2011
- *  Description: 
+ *  Description:
  */
 
 import (
@@ -16,6 +16,8 @@ import (
 	"strings"
 )
 
+// Deprecated: These globals are shared by every test in the binary. They are
+// only consulted when Test is not given a Format option.
 var (
 	Verbose bool       // If true more verbose errors are logged.
 	MsgFmt  = "%s: %s" // Result output format. Can be any 2-argument string.
@@ -35,3 +37,17 @@ func msg(name string, v ...interface{}) string {
 	return sprint(v...)
 }
 func msgname(name, typ string) string { return strings.Join([]string{name, typ}, " ") }
+
+// Join the name of a sub-test to the name of its parent.
+func joinName(parent, name string) string {
+	switch {
+	case parent == "":
+		return name
+	case name == "":
+		return parent
+	}
+	return parent + nameSep + name
+}
+
+// Separates the names of a T and its sub-T's.
+const nameSep = ": "
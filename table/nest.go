@@ -0,0 +1,66 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    nest.go
+ *  Description: Table elements containing tables.
+ */
+
+// An element which is a table of elements itself, like a configuration with
+// its own inputs. The elements of the inner table are tested in place of the
+// element, and named after it (e.g. "config A/input 3"). The element need not
+// implement Element, but may have Before and After methods, which are called
+// before and after the whole inner table. Options apply to the inner table as
+// they do to the outer one, except that an element containing a table does
+// not time out as a whole.
+type ElementTable interface {
+	Table() interface{} // A table of elements, as would be passed to Test.
+}
+
+// An ElementTable as an Element testing its inner table.
+type tableElem struct {
+	hooks
+	test func(t T)
+}
+
+func (e tableElem) Test(t T) { e.test(t) }
+
+// Test the inner table of the element named name. Only failures of the
+// element itself (e.g. in its Before method) are reported for it; its inner
+// elements are reported like any others.
+func (r *runner) testTable(j job, elem ElementTable) {
+	sub := r.t.sub(j.name)
+	sub.loc = j.loc
+	sub.state = newElemState()
+	defer sub.state.cancel()
+	res := elementTest(sub, tableElem{hooks{elem}, func(t T) { r.nest(t.(*testingT), j.name, elem.Table()) }})
+	if res.Failed {
+		r.done(res)
+	}
+}
+
+// The table of an ElementTable, such as a MatrixTable, or table itself.
+func unwrapTable(table interface{}) interface{} {
+	if tab, ok := table.(ElementTable); ok {
+		return tab.Table()
+	}
+	return table
+}
+
+// Test an inner table, named name, with a runner reporting to r's root.
+func (r *runner) nest(t *testingT, name string, table interface{}) {
+	val, _ := validateTable(t, unwrapTable(table))
+	cfg := *r.cfg
+	cfg.locs = nil
+	child := newRunner(r.t)
+	child.cfg, child.prefix = &cfg, name+"/"
+	child.parent = r
+	if r.parent != nil {
+		child.parent = r.parent
+	}
+	child.parent.addTable(tableLen(val))
+	rangeTable(t, child, val)
+	child.wait()
+}
@@ -0,0 +1,96 @@
+package table
+
+/*  Filename:    nest_test.go
+ *  Description: For testing nest.go
+ */
+
+import (
+	"testing"
+)
+
+// A configuration with its own inputs, logging calls to its methods.
+type configElem struct {
+	name   string
+	inputs []runElem
+	calls  *[]string
+}
+
+func (test configElem) String() string     { return test.name }
+func (test configElem) Table() interface{} { return test.inputs }
+func (test configElem) Before(t T)         { *test.calls = append(*test.calls, "before "+test.name) }
+func (test configElem) After(t T)          { *test.calls = append(*test.calls, "after "+test.name) }
+
+// An element containing a table which does not implement Before or After.
+type plainNest []interface{}
+
+func (test plainNest) Table() interface{} { return []interface{}(test) }
+
+func TestNestedTable(t *testing.T) {
+	var calls []string
+	rep := new(recordReporter)
+	ft := fauxTest("nest", func(t T) {
+		testWith(t, []interface{}{
+			configElem{"config A", []runElem{"", "bad"}, &calls},
+			runElem(""),
+			plainNest{runElem("worse"), plainNest{runElem("")}},
+		}, Report(rep))
+	})
+	if !ft.failed {
+		t.Error("inner failures did not fail the table")
+	}
+	want := []string{
+		"nest: config A 0/table.runElem 1: bad",
+		"nest: table.plainNest 2/table.runElem 0: worse",
+	}
+	if got := sprint(ft.log); got != sprint(want) {
+		t.Errorf("unexpected log %v", got)
+	}
+	if sprint(calls) != "[before config A after config A]" {
+		t.Errorf("unexpected calls %v", calls)
+	}
+	var names []string
+	for _, res := range rep.results {
+		names = append(names, res.Name)
+	}
+	wantNames := []string{
+		"nest: config A 0/table.runElem 0",
+		"nest: config A 0/table.runElem 1",
+		"nest: table.runElem 1",
+		"nest: table.plainNest 2/table.runElem 0",
+		"nest: table.plainNest 2/table.plainNest 1/table.runElem 0",
+	}
+	if sprint(names) != sprint(wantNames) {
+		t.Errorf("unexpected results %q", names)
+	}
+	if rep.summary.Failed != 2 {
+		t.Errorf("unexpected failures %d", rep.summary.Failed)
+	}
+}
+
+// An element whose inner table is invalid.
+type badNest struct{}
+
+func (test badNest) Table() interface{} { return 3 }
+
+func TestNestedTableErrors(t *testing.T) {
+	rep := new(recordReporter)
+	ft := fauxTest("nest", func(t T) {
+		testWith(t, []interface{}{badNest{}, runElem("")}, Report(rep))
+	})
+	if !ft.failed || len(ft.log) != 1 || !ft.logLineLike(0, `^nest: table.badNest 0: .*table int is not a slice`) {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+	if len(rep.results) != 2 || rep.results[0].Name != "nest: table.badNest 0" || !rep.results[0].Failed {
+		t.Errorf("unexpected results %#v", rep.results)
+	}
+}
+
+func TestNestedTableFailFast(t *testing.T) {
+	rep := new(recordReporter)
+	fauxTest("nest", func(t T) {
+		testWith(t, []interface{}{plainNest{runElem("bad"), runElem("")}, runElem("")}, FailFast(), Report(rep))
+	})
+	if len(rep.results) != 1 || rep.summary.NotRun != 2 {
+		t.Errorf("unexpected summary %#v", rep.summary)
+	}
+}
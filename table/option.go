@@ -0,0 +1,129 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    option.go
+ *  Description: Options configuring a single call to Test.
+ */
+
+import (
+	"math/rand"
+	"reflect"
+	"runtime"
+	"time"
+)
+
+// The configuration of a single call to Test.
+type config struct {
+	format       Formatter
+	naming       func(key, elem interface{}) string
+	less         func(a, b interface{}) bool // Orders map keys.
+	order        func(T, []job)
+	parallel     int
+	timeout      time.Duration
+	reporters    []Reporter
+	maxFailures  int // Zero means there is no maximum.
+	coverage     bool
+	cover        coverFunc        // Nil means coverRun.
+	budget       time.Duration    // Zero means elements have no budget.
+	clock        func() time.Time // Nil means time.Now.
+	slowest      int
+	slow         time.Duration // The -table.slow flag when the table started.
+	leaks        bool
+	grace        time.Duration            // How long leaked goroutines may run.
+	guard        map[string]reflect.Value // Pointers to guarded globals; nil when unguarded.
+	snapshotFile string
+	baselineFile string
+	baseline     *snapshots
+	file         string // File containing the call to Test.
+	line         int    // Line of the call to Test.
+	arg          int    // Argument of the call holding the table.
+	locs         *locations
+	prefix       string // Joined to element names by the table's root T.
+	site         string // The file and line of the call to Test, if known.
+}
+
+// Whether elements are tested one at a time, even with the Parallel option.
+// The Leaks and Guard options need this to attribute goroutines or changes to
+// global state to the element responsible.
+func (c *config) serial() bool { return c.leaks || c.guard != nil }
+
+func newConfig(opts []Option) *config {
+	c := &config{slow: *slow}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// An Option configures a single call to Test.
+type Option func(*config)
+
+// Lay out messages logged by the table's elements with f.
+func Format(f Formatter) Option { return func(c *config) { c.format = f } }
+
+// Name elements with fn instead of by type and index. The key passed to fn is
+// an element's index in a slice table, or its key in a map table.
+func Naming(fn func(key, elem interface{}) string) Option {
+	return func(c *config) { c.naming = fn }
+}
+
+// Test elements in a pseudo-random order determined by seed. A zero seed is
+// replaced by one derived from the clock, and logged so the order can be
+// reproduced.
+func Shuffle(seed int64) Option {
+	return func(c *config) {
+		c.order = func(t T, jobs []job) {
+			s := seed
+			if s == 0 {
+				s = time.Now().UnixNano()
+				t.Logf("shuffled with seed %d", s)
+			}
+			rand.New(rand.NewSource(s)).Shuffle(len(jobs), func(i, j int) {
+				jobs[i], jobs[j] = jobs[j], jobs[i]
+			})
+		}
+	}
+}
+
+// Test elements in reverse order.
+func Reverse() Option {
+	return func(c *config) {
+		c.order = func(t T, jobs []job) {
+			for i, j := 0, len(jobs)-1; i < j; i, j = i+1, j-1 {
+				jobs[i], jobs[j] = jobs[j], jobs[i]
+			}
+		}
+	}
+}
+
+// Test up to n elements concurrently. When n is not positive, GOMAXPROCS
+// elements are tested concurrently. Elements sharing state should not be
+// tested in parallel. Parallel has no effect with the Leaks or Guard options,
+// which test elements one at a time.
+func Parallel(n int) Option {
+	return func(c *config) {
+		if n <= 0 {
+			n = runtime.GOMAXPROCS(0)
+		}
+		c.parallel = n
+	}
+}
+
+// Fail elements which don't finish within d. An element which times out is
+// abandoned; its Context is canceled and anything it logs afterwards is
+// discarded.
+func Timeout(d time.Duration) Option { return func(c *config) { c.timeout = d } }
+
+// Send structured results to r. Report may be given more than once.
+func Report(r Reporter) Option { return func(c *config) { c.reporters = append(c.reporters, r) } }
+
+// Stop testing elements after the first failure.
+func FailFast() Option { return MaxFailures(1) }
+
+// Stop testing elements after n failures. Elements which are not tested are
+// counted in the table's Summary, and logged along with the reason they were
+// not run. A non-positive n removes the limit.
+func MaxFailures(n int) Option { return func(c *config) { c.maxFailures = n } }
@@ -0,0 +1,180 @@
+package table
+
+/*  Filename:    option_test.go
+ *  Description: For testing option.go
+ */
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Records the order elements are tested in.
+type orderElem struct {
+	order *[]int
+	i     int
+}
+
+func (test orderElem) Test(t T) { *test.order = append(*test.order, test.i) }
+
+func orderTable(order *[]int, n int) (table []orderElem) {
+	for i := 0; i < n; i++ {
+		table = append(table, orderElem{order, i})
+	}
+	return
+}
+
+type orderTest struct {
+	opts  []Option
+	order []int
+}
+
+func (test orderTest) Test(t T) {
+	var order []int
+	ft := fauxTest("order", func(t T) { testWith(t, orderTable(&order, 5), test.opts...) })
+	if ft.failed {
+		t.Errorf("unexpected failure %v", ft.log)
+	}
+	if test.order != nil && !reflect.DeepEqual(order, test.order) {
+		t.Errorf("order %v != %v", order, test.order)
+	}
+	if len(order) != 5 {
+		t.Errorf("tested %d elements", len(order))
+	}
+}
+
+var orderTests = []orderTest{
+	{nil, []int{0, 1, 2, 3, 4}},
+	{[]Option{Reverse()}, []int{4, 3, 2, 1, 0}},
+	{[]Option{Shuffle(1)}, nil},
+	{[]Option{Shuffle(0)}, nil},
+}
+
+func TestOrder(t *testing.T) {
+	for i, test := range orderTests {
+		elementTest(subT(sprintf("order %d", i), t), test)
+	}
+	var first, second []int
+	fauxTest("shuffle", func(t T) { testWith(t, orderTable(&first, 20), Shuffle(7)) })
+	fauxTest("shuffle", func(t T) { testWith(t, orderTable(&second, 20), Shuffle(7)) })
+	if !reflect.DeepEqual(first, second) {
+		t.Errorf("shuffle with the same seed is not reproducible %v %v", first, second)
+	}
+}
+
+func TestNaming(t *testing.T) {
+	ft := fauxTest("", func(t T) {
+		testWith(t, []runElem{"bad"}, Naming(func(key, elem interface{}) string {
+			return sprintf("row %v %q", key, elem)
+		}))
+	})
+	if !ft.logLike(`^row 0 "bad": bad$`) {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+}
+
+// Waits until n elements are running concurrently.
+type parallelElem struct {
+	mu      *sync.Mutex
+	running *int
+	n       int
+}
+
+func (test parallelElem) Test(t T) {
+	test.mu.Lock()
+	*test.running++
+	test.mu.Unlock()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); {
+		test.mu.Lock()
+		running := *test.running
+		test.mu.Unlock()
+		if running >= test.n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Errorf("%d elements not running concurrently", test.n)
+}
+
+func TestParallel(t *testing.T) {
+	var mu sync.Mutex
+	var running int
+	table := make([]parallelElem, 8)
+	for i := range table {
+		table[i] = parallelElem{&mu, &running, 4}
+	}
+	lt := &lockedT{ft: new(fauxT)}
+	testWith(lt, table, Parallel(4))
+	if lt.ft.failed {
+		t.Errorf("unexpected failure %v", lt.ft.log)
+	}
+}
+
+// Sleeps for d unless its context is canceled first, closing done when it
+// returns.
+type sleepElem struct {
+	d    time.Duration
+	done chan struct{}
+}
+
+func (test sleepElem) Test(t T) {
+	defer close(test.done)
+	select {
+	case <-time.After(test.d):
+		t.Error("woke up")
+	case <-Context(t).Done():
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	done := make(chan struct{})
+	ft := fauxTest("timeout", func(t T) {
+		testWith(t, []sleepElem{{time.Second, done}}, Timeout(10*time.Millisecond))
+	})
+	<-done
+	if !ft.failed || ft.Len() != 1 || !ft.logLineLike(0, "timed out after 10ms") {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+}
+
+type maxFailuresTest struct {
+	opts    []Option
+	results int
+	log     string
+}
+
+func (test maxFailuresTest) Test(t T) {
+	rep := new(recordReporter)
+	ft := fauxTest("max failures", func(t T) {
+		testWith(t, []runElem{"", "bad", "", "worse", "worst"}, append(test.opts, Report(rep))...)
+	})
+	if len(rep.results) != test.results {
+		t.Errorf("unexpected results %v", rep.results)
+	}
+	switch {
+	case test.log == "" && rep.summary.NotRun != 0:
+		t.Errorf("unexpected summary %v", rep.summary)
+	case test.log == "":
+	case !ft.logLike(test.log):
+		t.Errorf("missing log %q: %v", test.log, ft.log)
+	case rep.summary.NotRun != 5-test.results || rep.summary.Reason == "":
+		t.Errorf("unexpected summary %v", rep.summary)
+	}
+}
+
+var maxFailuresTests = []maxFailuresTest{
+	{nil, 5, ""},
+	{[]Option{FailFast()}, 2, `^max failures: 3 of 5 elements not run: stopped after the first failed element$`},
+	{[]Option{MaxFailures(2)}, 4, `^max failures: 1 of 5 elements not run: stopped after 2 failed elements$`},
+	{[]Option{MaxFailures(3)}, 5, ""},
+	{[]Option{Reverse(), FailFast()}, 1, `^max failures: 4 of 5 elements not run`},
+	{[]Option{FailFast(), MaxFailures(0)}, 5, ""},
+}
+
+func TestMaxFailures(t *testing.T) {
+	for i, test := range maxFailuresTests {
+		elementTest(subT(sprintf("max failures %d", i), t), test)
+	}
+}
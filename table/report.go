@@ -0,0 +1,158 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    report.go
+ *  Description: Structured results of table tests.
+ */
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"strings"
+	"time"
+)
+
+// The outcome of testing a single table element.
+type Result struct {
+	Name   string `json:"name"`            // Name of the element.
+	Failed bool   `json:"failed"`          // The element failed.
+	Fatal  bool   `json:"fatal,omitempty"` // The element was stopped by FailNow (or Fatal).
+	XFail  string `json:"xfail,omitempty"` // Why the element was expected to fail.
+	Meta   *Meta  `json:"meta,omitempty"`  // Describes the element, if it describes itself.
+
+	// Running times of the element as a whole, and of its Before and After
+	// methods.
+	Elapsed time.Duration `json:"elapsed,omitempty"`
+	Before  time.Duration `json:"before,omitempty"`
+	After   time.Duration `json:"after,omitempty"`
+
+	// Source lines covered by the element, by file (e.g. "table.go:10-12,15"),
+	// and whether other elements cover them all (see Coverage). Both are
+	// only set in the Summary, as they are measured after the table finishes.
+	Coverage  []string `json:"coverage,omitempty"`
+	Redundant bool     `json:"redundant,omitempty"`
+	blocks    []coverBlock
+
+	// Serialization of the value returned by an ElementObserve.
+	Observed string   `json:"observed,omitempty"`
+	Log      []string `json:"log,omitempty"` // Formatted messages logged by the element.
+}
+
+// The outcome of a call to Test.
+type Summary struct {
+	Name    string   `json:"name,omitempty"`   // Name of the enclosing Go test, if known.
+	Results []Result `json:"results"`          // Element results, in the order they finished.
+	Failed  int      `json:"failed"`           // The number of failed elements.
+	NotRun  int      `json:"not_run"`          // Elements not tested; -1 when the number is unknown.
+	Reason  string   `json:"reason,omitempty"` // Why elements were not tested.
+}
+
+// A Reporter receives structured results from a call to Test. Calls to a
+// Reporter's methods are never concurrent, even when elements are tested in
+// parallel.
+type Reporter interface {
+	Element(r Result) // Called as each element finishes.
+	Done(s Summary)   // Called after the table has finished.
+}
+
+// Writes a line of JSON for each element, and a final line for the summary.
+// Element lines have the keys "name", "failed", "log", "meta", "elapsed",
+// "before" and "after" (in nanoseconds), "coverage" and "observed". The
+// summary line has the keys "name", "failed", "elements" (the number of
+// elements tested), "not_run" and "reason".
+func JSONReporter(w io.Writer) Reporter { return jsonReporter{json.NewEncoder(w)} }
+
+type jsonReporter struct{ enc *json.Encoder }
+
+func (r jsonReporter) Element(res Result) { r.enc.Encode(res) }
+func (r jsonReporter) Done(s Summary) {
+	r.enc.Encode(struct {
+		Name     string `json:"name,omitempty"`
+		Failed   int    `json:"failed"`
+		Elements int    `json:"elements"`
+		NotRun   int    `json:"not_run"`
+		Reason   string `json:"reason,omitempty"`
+	}{s.Name, s.Failed, len(s.Results), s.NotRun, s.Reason})
+}
+
+// Writes a JUnit XML test suite when the table finishes. Element metadata is
+// written as test case properties.
+func JUnitReporter(w io.Writer) Reporter { return junitReporter{w} }
+
+type junitReporter struct{ w io.Writer }
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitProperties struct {
+	Properties []junitProperty `xml:"property"`
+}
+
+type junitCase struct {
+	Name       string           `xml:"name,attr"`
+	Properties *junitProperties `xml:"properties,omitempty"`
+	Failure    *junitFailure    `xml:"failure,omitempty"`
+}
+
+// Element metadata as JUnit test case properties.
+func junitMeta(m *Meta) *junitProperties {
+	if m == nil {
+		return nil
+	}
+	var props []junitProperty
+	if m.Description != "" {
+		props = append(props, junitProperty{"description", m.Description})
+	}
+	for _, issue := range m.Issues {
+		props = append(props, junitProperty{"issue", issue})
+	}
+	if m.Owner != "" {
+		props = append(props, junitProperty{"owner", m.Owner})
+	}
+	return &junitProperties{props}
+}
+
+type junitSuite struct {
+	XMLName  xml.Name    `xml:"testsuite"`
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Skipped  int         `xml:"skipped,attr,omitempty"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+func (r junitReporter) Element(res Result) {}
+func (r junitReporter) Done(s Summary) {
+	suite := junitSuite{Name: s.Name, Tests: len(s.Results), Failures: s.Failed}
+	if s.NotRun > 0 {
+		suite.Skipped = s.NotRun
+	}
+	for _, res := range s.Results {
+		c := junitCase{Name: res.Name, Properties: junitMeta(res.Meta)}
+		if res.Failed {
+			c.Failure = &junitFailure{"failed", strings.Join(res.Log, "\n")}
+			if res.Fatal {
+				c.Failure.Message = "fatal"
+			}
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	p, err := xml.MarshalIndent(suite, "", "\t")
+	if err != nil {
+		return
+	}
+	r.w.Write([]byte(xml.Header))
+	r.w.Write(p)
+	r.w.Write([]byte("\n"))
+}
@@ -0,0 +1,46 @@
+package table
+
+/*  Filename:    report_test.go
+ *  Description: For testing report.go
+ */
+
+import (
+	"bytes"
+	"testing"
+)
+
+type reporterTest struct {
+	rep  func(*bytes.Buffer) Reporter
+	want string
+}
+
+func (test reporterTest) Test(t T) {
+	buf := new(bytes.Buffer)
+	fauxTest("", func(t T) {
+		testWith(t, []runElem{"", "bad"}, Report(test.rep(buf)), fakeClock(0))
+	})
+	if out := buf.String(); out != test.want {
+		t.Errorf("unexpected report %q\nwant %q", out, test.want)
+	}
+}
+
+var reporterTests = []reporterTest{
+	{func(w *bytes.Buffer) Reporter { return JSONReporter(w) }, `{"name":"table.runElem 0","failed":false}
+{"name":"table.runElem 1","failed":true,"log":["table.runElem 1: bad"]}
+{"failed":1,"elements":2,"not_run":0}
+`},
+	{func(w *bytes.Buffer) Reporter { return JUnitReporter(w) }, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="" tests="2" failures="1">
+	<testcase name="table.runElem 0"></testcase>
+	<testcase name="table.runElem 1">
+		<failure message="failed">table.runElem 1: bad</failure>
+	</testcase>
+</testsuite>
+`},
+}
+
+func TestReporters(t *testing.T) {
+	for i, test := range reporterTests {
+		elementTest(subT(sprintf("reporter %d", i), t), test)
+	}
+}
@@ -0,0 +1,232 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    run.go
+ *  Description: Scheduling of table elements.
+ */
+
+import (
+	"sync"
+	"time"
+)
+
+// Returned by range callbacks to stop iteration without an error.
+var errStop = error_("stop testing")
+
+// A table element waiting to be tested.
+type job struct {
+	key  interface{} // Index or key of the element in its table.
+	name string
+	loc  string
+	elem interface{}
+}
+
+// Tests the elements of a single table as configured by its root T.
+type runner struct {
+	t       *testingT
+	cfg     *config
+	sem     chan bool // Limits the number of concurrent elements.
+	wg      sync.WaitGroup
+	queue   []job // Elements held until finish when the table is ordered.
+	mu      sync.Mutex
+	summary Summary
+	stopped bool
+	total   int // The number of elements in the table; -1 when unknown.
+
+	// Runners of inner tables (see ElementTable) report to their parent,
+	// the runner of the outermost table.
+	parent *runner
+	prefix string // Prepended to element names.
+}
+
+func newRunner(t *testingT) *runner {
+	if t.cfg == nil {
+		t.cfg = new(config)
+	}
+	r := &runner{t: t, cfg: t.cfg, total: -1}
+	if r.cfg.parallel > 1 && !r.cfg.serial() {
+		r.sem = make(chan bool, r.cfg.parallel)
+	}
+	return r
+}
+
+// The name of an element, unless the table was given a Naming option.
+func (r *runner) name(key, elem interface{}, name string) string {
+	if r.cfg.naming != nil {
+		name = r.cfg.naming(key, elem)
+	}
+	return r.prefix + name
+}
+
+func (r *runner) isStopped() bool {
+	if r.parent != nil {
+		return r.parent.isStopped()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stopped
+}
+
+// Test an element, or queue it if the table is ordered. Returns errStop once
+// no more elements will be tested.
+func (r *runner) add(j job) error {
+	if r.cfg.order != nil {
+		r.queue = append(r.queue, j)
+		return nil
+	}
+	return r.start(j)
+}
+
+func (r *runner) start(j job) error {
+	if r.isStopped() {
+		return errStop
+	}
+	if r.sem == nil {
+		r.test(j)
+		return nil
+	}
+	r.sem <- true
+	if r.isStopped() {
+		<-r.sem
+		return errStop
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer func() { <-r.sem }()
+		r.test(j)
+	}()
+	return nil
+}
+
+func (r *runner) test(j job) {
+	if elem, ok := j.elem.(ElementTable); ok {
+		r.testTable(j, elem)
+		return
+	}
+	sub := r.t.sub(j.name)
+	if r.cfg.coverSkip(sub.name) {
+		return
+	}
+	sub.loc = j.loc
+	sub.state = newElemState()
+	defer sub.state.cancel()
+	r.done(r.elementTest(sub, j.elem))
+}
+
+// Test an element, abandoning it if it exceeds the configured timeout.
+func (r *runner) elementTest(t *testingT, elem interface{}) Result {
+	test, err := mustElement(t, elem)
+	if err != nil {
+		return t.state.result(t.name)
+	}
+	if r.cfg.timeout <= 0 {
+		return elementTest(t, test)
+	}
+	done := make(chan Result, 1)
+	go func() { done <- elementTest(t, test) }()
+	timer := time.NewTimer(r.cfg.timeout)
+	defer timer.Stop()
+	select {
+	case res := <-done:
+		return res
+	case <-timer.C:
+	}
+	t.Errorf("timed out after %v", r.cfg.timeout)
+	// The abandoned goroutine can't resolve an expected failure.
+	if reason := t.state.result(t.name).XFail; reason != "" {
+		expectedFailure(t, reason)
+	}
+	t.state.close()
+	return t.state.result(t.name)
+}
+
+func (r *runner) done(res Result) {
+	if r.parent != nil {
+		r.parent.done(res)
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.summary.Results = append(r.summary.Results, res)
+	if res.Failed {
+		r.summary.Failed++
+	}
+	if max := r.cfg.maxFailures; max > 0 && r.summary.Failed >= max {
+		r.stopped = true
+	}
+	for _, rep := range r.cfg.reporters {
+		rep.Element(res)
+	}
+}
+
+// Count the elements of an inner table, n of them (-1 when unknown), in place
+// of the element containing it.
+func (r *runner) addTable(n int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	switch {
+	case n < 0:
+		r.total = -1
+	case r.total >= 0:
+		r.total += n - 1
+	}
+}
+
+// Test any queued elements and wait for all elements to finish.
+func (r *runner) wait() {
+	if r.cfg.order != nil {
+		r.cfg.order(r.t, r.queue)
+		for _, j := range r.queue {
+			if r.start(j) != nil {
+				break
+			}
+		}
+	}
+	r.wg.Wait()
+}
+
+// Wait for all elements to finish and report the table's summary.
+func (r *runner) finish() {
+	r.wait()
+	if r.stopped {
+		r.notRun()
+	}
+	if r.cfg.coverage && !coverChild() {
+		logCoverage(r.t, r.summary)
+	}
+	if r.cfg.slowest > 0 {
+		logSlowest(r.t, r.summary, r.cfg.slowest)
+	}
+	finishSnapshots(r.t, r.summary)
+	if r.cfg.baseline != nil {
+		closeSnapshots(r.t, r.cfg.baselineFile, r.summary)
+	}
+	if named, ok := r.t.t.(interface {
+		Name() string
+	}); ok {
+		r.summary.Name = named.Name()
+	}
+	for _, rep := range r.cfg.reporters {
+		rep.Done(r.summary)
+	}
+}
+
+// Count and log the elements which were not tested.
+func (r *runner) notRun() {
+	s := &r.summary
+	s.Reason = sprintf("stopped after %d failed elements", s.Failed)
+	if s.Failed == 1 {
+		s.Reason = "stopped after the first failed element"
+	}
+	if r.total < 0 {
+		s.NotRun = -1
+		r.t.Logf("remaining elements not run: %s", s.Reason)
+		return
+	}
+	s.NotRun = r.total - len(s.Results)
+	r.t.Logf("%d of %d elements not run: %s", s.NotRun, r.total, s.Reason)
+}
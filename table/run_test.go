@@ -0,0 +1,98 @@
+package table
+
+/*  Filename:    run_test.go
+ *  Description: For testing run.go
+ */
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// A fauxT safe for use by parallel elements.
+type lockedT struct {
+	sync.Mutex
+	ft *fauxT
+}
+
+func (t *lockedT) Fail()                   { t.Lock(); defer t.Unlock(); t.ft.Fail() }
+func (t *lockedT) FailNow()                { t.Lock(); defer t.Unlock(); t.ft.FailNow() }
+func (t *lockedT) Failed() bool            { t.Lock(); defer t.Unlock(); return t.ft.Failed() }
+func (t *lockedT) Log(args ...interface{}) { t.Lock(); defer t.Unlock(); t.ft.Log(args...) }
+func (t *lockedT) Error(args ...interface{}) {
+	t.Lock()
+	defer t.Unlock()
+	t.ft.Error(args...)
+}
+func (t *lockedT) Fatal(args ...interface{}) {
+	t.Lock()
+	defer t.Unlock()
+	t.ft.Fatal(args...)
+}
+func (t *lockedT) Logf(format string, args ...interface{})   { t.Log(sprintf(format, args...)) }
+func (t *lockedT) Errorf(format string, args ...interface{}) { t.Error(sprintf(format, args...)) }
+func (t *lockedT) Fatalf(format string, args ...interface{}) { t.Fatal(sprintf(format, args...)) }
+
+// Records the results passed to it.
+type recordReporter struct {
+	results []Result
+	summary *Summary
+}
+
+func (r *recordReporter) Element(res Result) { r.results = append(r.results, res) }
+func (r *recordReporter) Done(s Summary)     { r.summary = &s }
+
+// An element which fails when its value is non-empty.
+type runElem string
+
+func (test runElem) Test(t T) {
+	if test != "" {
+		t.Error(string(test))
+	}
+}
+
+func TestRunnerResults(t *testing.T) {
+	rep := new(recordReporter)
+	ft := fauxTest("results", func(t T) {
+		testWith(t, []runElem{"", "bad", ""}, Report(rep), fakeClock(0))
+	})
+	if !ft.failed {
+		t.Error("failed element did not fail the test")
+	}
+	want := []Result{
+		{Name: "results: table.runElem 0"},
+		{Name: "results: table.runElem 1", Failed: true, Log: []string{"results: table.runElem 1: bad"}},
+		{Name: "results: table.runElem 2"},
+	}
+	if !reflect.DeepEqual(rep.results, want) {
+		t.Errorf("unexpected results %#v", rep.results)
+	}
+	if rep.summary == nil {
+		t.Fatal("summary not reported")
+	}
+	if rep.summary.Failed != 1 || !reflect.DeepEqual(rep.summary.Results, want) {
+		t.Errorf("unexpected summary %#v", *rep.summary)
+	}
+}
+
+// Failed reports on the element, not on the whole table.
+type runFailedElem bool
+
+func (test runFailedElem) Test(t T) {
+	if t.Failed() {
+		t.Error("failed before failing")
+	}
+	if test {
+		t.Fail()
+	}
+}
+
+func TestRunnerFailed(t *testing.T) {
+	ft := fauxTest("failed", func(t T) {
+		testWith(t, []runFailedElem{true, false})
+	})
+	if !ft.failed || ft.Len() != 0 {
+		t.Errorf("unexpected failure %v %v", ft.failed, ft.log)
+	}
+}
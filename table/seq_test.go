@@ -0,0 +1,94 @@
+package table
+
+/*  Filename:    seq_test.go
+ *  Description: For testing iterator tables in table.go
+ */
+
+import (
+	"iter"
+	"testing"
+)
+
+// Yields the given elements, counting how many were requested.
+func seqOf(yielded *int, elems ...runElem) iter.Seq[runElem] {
+	return func(yield func(runElem) bool) {
+		for _, e := range elems {
+			*yielded++
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+func seq2Of(pairs ...string) iter.Seq2[string, Element] {
+	return func(yield func(string, Element) bool) {
+		for i := 0; i+1 < len(pairs); i += 2 {
+			if !yield(pairs[i], runElem(pairs[i+1])) {
+				return
+			}
+		}
+	}
+}
+
+func resultNames(rep *recordReporter) (names []string) {
+	for _, res := range rep.results {
+		names = append(names, res.Name)
+	}
+	return
+}
+
+func TestSeqTable(t *testing.T) {
+	var n int
+	rep := new(recordReporter)
+	ft := fauxTest("seq", func(t T) { testWith(t, seqOf(&n, "", "bad", ""), Report(rep)) })
+	want := []string{"seq: table.runElem 1: bad"}
+	if got := sprint(ft.log); got != sprint(want) {
+		t.Errorf("unexpected log %v", got)
+	}
+	if names := resultNames(rep); len(names) != 3 || n != 3 {
+		t.Errorf("unexpected results %q (%d yielded)", names, n)
+	}
+}
+
+func TestSeq2Table(t *testing.T) {
+	rep := new(recordReporter)
+	ft := fauxTest("seq", func(t T) { testWith(t, seq2Of("first", "", "second", "bad"), Report(rep)) })
+	want := []string{"seq: second: bad"}
+	if got := sprint(ft.log); got != sprint(want) {
+		t.Errorf("unexpected log %v", got)
+	}
+	if names := resultNames(rep); sprint(names) != "[seq: first seq: second]" {
+		t.Errorf("unexpected results %q", names)
+	}
+}
+
+func TestSeqTableFailFast(t *testing.T) {
+	var n int
+	rep := new(recordReporter)
+	ft := fauxTest("seq", func(t T) { testWith(t, seqOf(&n, "bad", "", ""), FailFast(), Report(rep)) })
+	if n != 2 || len(rep.results) != 1 {
+		t.Errorf("iteration not stopped: %d yielded, %d tested", n, len(rep.results))
+	}
+	if rep.summary.NotRun != -1 || !ft.logLike(`^seq: remaining elements not run: stopped after the first failed element$`) {
+		t.Errorf("unexpected summary %#v; log %v", rep.summary, ft.log)
+	}
+}
+
+// An element containing an iterator table.
+type seqNest []runElem
+
+func (test seqNest) Table() interface{} { var n int; return seqOf(&n, test...) }
+
+var seqErrorTests = []metaTestSimple{
+	{"empty", func(t T) { var n int; testWith(t, seqOf(&n)) }, []string{`empty table`}},
+	{"nil", func(t T) { testWith(t, iter.Seq[runElem](nil)) }, []string{`nil iterator table`}},
+	{"not an iterator", func(t T) { testWith(t, func(int) bool { return true }) }, []string{`is not an iterator`}},
+	{"nested", func(t T) { testWith(t, []seqNest{{"", "nested"}}) }, []string{`: table.seqNest 0/table.runElem 1: nested$`}},
+}
+
+func TestSeqTableErrors(t *testing.T) {
+	for i, test := range seqErrorTests {
+		elementTest(subT(sprintf("seq error %d", i), t), test)
+	}
+}
@@ -0,0 +1,420 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    snapshot.go
+ *  Description: Snapshots of values produced by table elements.
+ */
+
+import (
+	"encoding"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Compare a serialization of v to the snapshot recorded for the element being
+// tested by t, failing the element with a diff when they differ. Snapshots of
+// the tables in a Go test are kept in one file, testdata/snapshots/<test
+// name>.snap by default (see SnapshotFile), with an entry for each element
+// named after it. Elements may take several snapshots, which are numbered, as
+// are the snapshots of elements with the same name in different tables. When
+// tests are run with the -table.update flag, snapshots are recorded instead,
+// and entries for elements which no longer exist are removed; otherwise
+// obsolete entries are logged. Entries of elements which failed are never
+// obsolete.
+//
+// Values are serialized deterministically: maps are sorted by key (as map
+// tables are), structs and collections are printed one field or element per
+// line, and values implementing encoding.TextMarshaler are printed as text.
+// Unexported struct fields are included.
+func Snapshot(t T, v interface{}) {
+	if s, ok := t.(interface {
+		Snapshot(interface{})
+	}); ok {
+		s.Snapshot(v)
+		return
+	}
+	t.Errorf("can't take a snapshot with %T; use the T passed to an element", t)
+}
+
+// Keep the snapshots of the table in the file at path.
+func SnapshotFile(path string) Option { return func(c *config) { c.snapshotFile = path } }
+
+// Snapshot is Snapshot(t, v).
+func (t *testingT) Snapshot(v interface{}) {
+	s := t.snapshots()
+	if s == nil {
+		t.Error("can't take a snapshot outside of a table element")
+		return
+	}
+	got := serialize(v)
+	key, want, ok, err := s.take(strings.TrimPrefix(t.name, t.cfg.prefix), got)
+	switch {
+	case err != nil:
+		t.Error(err)
+	case *update:
+	case !ok:
+		t.Errorf("no snapshot %q in %s (run with -table.update to create it)\n%s", key, s.path, got)
+	case got != want:
+		t.Errorf("snapshot %q changed (run with -table.update to accept it)\n%s", key, lineDiff(got, want))
+	}
+}
+
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// The default snapshot file of tables tested by t.
+func snapshotPath(t T) string {
+	name := "table"
+	if named, ok := t.(interface {
+		Name() string
+	}); ok {
+		name = unsafeName.ReplaceAllString(named.Name(), "_")
+	}
+	return filepath.Join("testdata", "snapshots", name+".snap")
+}
+
+func (c *config) snapshotPath(t T) string {
+	if c.snapshotFile != "" {
+		return c.snapshotFile
+	}
+	return snapshotPath(t)
+}
+
+// The snapshot files in use (including baselines), by path. The tables of a
+// Go test share a file, which is finished with the test. When the test can't
+// register a cleanup function the file is finished with each table instead.
+var snapshotFiles = struct {
+	sync.Mutex
+	m map[string]*snapshots
+}{m: make(map[string]*snapshots)}
+
+// Open the file at path for the table tested by t.
+func openSnapshots(t *testingT, path, what string) *snapshots {
+	snapshotFiles.Lock()
+	defer snapshotFiles.Unlock()
+	s := snapshotFiles.m[path]
+	if s != nil {
+		return s
+	}
+	s = newSnapshots(path, what)
+	snapshotFiles.m[path] = s
+	if c, ok := t.t.(interface {
+		Cleanup(func())
+	}); ok {
+		s.shared = true
+		root := subT("", t.t)
+		c.Cleanup(func() {
+			snapshotFiles.Lock()
+			delete(snapshotFiles.m, path)
+			snapshotFiles.Unlock()
+			s.finish(root)
+		})
+	}
+	return s
+}
+
+// Record the outcome of a table tested by t in the file at path, if it was
+// opened, finishing the file unless it is shared with other tables.
+func closeSnapshots(t *testingT, path string, sum Summary) {
+	snapshotFiles.Lock()
+	s := snapshotFiles.m[path]
+	if s != nil && !s.shared {
+		delete(snapshotFiles.m, path)
+	}
+	snapshotFiles.Unlock()
+	if s == nil {
+		return
+	}
+	s.tested(t.cfg.prefix, sum)
+	if !s.shared {
+		s.finish(t)
+	}
+}
+
+// The snapshots of the table tested by t, opened on first use.
+func (t *testingT) snapshots() *snapshots {
+	if t.cfg == nil {
+		return nil
+	}
+	return openSnapshots(t, t.cfg.snapshotPath(t.t), "snapshots")
+}
+
+// Record the outcome of a table tested by t, finishing the snapshots it took
+// unless they are shared with other tables.
+func finishSnapshots(t *testingT, sum Summary) {
+	closeSnapshots(t, t.cfg.snapshotPath(t.t), sum)
+}
+
+// The entries of a snapshot file, loaded when first used.
+type snapshots struct {
+	sync.Mutex
+	path    string
+	what    string // What the entries are, in messages.
+	shared  bool   // Finished with the Go test rather than the table.
+	loaded  bool
+	entries map[string]string
+	taken   map[string]int  // Entries taken by each element.
+	used    map[string]bool // Entries compared (or recorded) by elements.
+	failed  map[string]bool // Elements which failed, whose entries are kept.
+	partial bool            // Elements of a table were not tested.
+	changed bool
+}
+
+func newSnapshots(path, what string) *snapshots {
+	return &snapshots{
+		path:   path,
+		what:   what,
+		taken:  make(map[string]int),
+		used:   make(map[string]bool),
+		failed: make(map[string]bool),
+	}
+}
+
+const snapshotHeader = "--- "
+
+func (s *snapshots) load() error {
+	if s.loaded {
+		return nil
+	}
+	s.loaded = true
+	s.entries = make(map[string]string)
+	p, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	var key string
+	var lines []string
+	flush := func() {
+		if lines != nil {
+			s.entries[key] = strings.TrimRight(strings.Join(lines, "\n"), "\n")
+		}
+	}
+	for i, line := range strings.Split(string(p), "\n") {
+		switch {
+		case strings.HasPrefix(line, snapshotHeader):
+			flush()
+			key, lines = line[len(snapshotHeader):], []string{}
+		case lines == nil && line != "":
+			return errorf("%s:%d: entry without a header", s.path, i+1)
+		case lines != nil:
+			lines = append(lines, line)
+		}
+	}
+	flush()
+	return nil
+}
+
+// Take an entry for the named element. Returns the entry's key and the
+// recorded entry, if there is one. When updating, got is recorded.
+func (s *snapshots) take(name, got string) (key, want string, ok bool, err error) {
+	s.Lock()
+	defer s.Unlock()
+	if err = s.load(); err != nil {
+		return
+	}
+	s.taken[name]++
+	key = name
+	if n := s.taken[name]; n > 1 {
+		key = sprintf("%s #%d", name, n)
+	}
+	s.used[key] = true
+	want, ok = s.entries[key]
+	if *update && (!ok || want != got) {
+		s.entries[key] = got
+		s.changed = true
+	}
+	return
+}
+
+// Record the outcome of a table whose element names are joined to prefix.
+func (s *snapshots) tested(prefix string, sum Summary) {
+	s.Lock()
+	defer s.Unlock()
+	for _, res := range sum.Results {
+		if res.Failed {
+			s.failed[strings.TrimPrefix(res.Name, prefix)] = true
+		}
+	}
+	s.partial = s.partial || sum.NotRun != 0
+}
+
+var repeatedKey = regexp.MustCompile(` #\d+$`)
+
+// Report or remove obsolete entries, and write any updated entries. Entries
+// are only obsolete when every element was tested, and their element passed.
+func (s *snapshots) finish(t *testingT) {
+	s.Lock()
+	defer s.Unlock()
+	if !s.loaded {
+		if _, err := os.Stat(s.path); err != nil {
+			return
+		}
+	}
+	if err := s.load(); err != nil {
+		t.Error(err)
+		return
+	}
+	var obsolete []string
+	if !s.partial {
+		for key := range s.entries {
+			if !s.used[key] && !s.failed[repeatedKey.ReplaceAllString(key, "")] {
+				obsolete = append(obsolete, key)
+			}
+		}
+	}
+	sort.Slice(obsolete, func(i, j int) bool { return naturalCompare(obsolete[i], obsolete[j]) < 0 })
+	if !*update {
+		if len(obsolete) > 0 {
+			t.Logf("obsolete %s in %s (run with -table.update to remove them): %q", s.what, s.path, obsolete)
+		}
+		return
+	}
+	for _, key := range obsolete {
+		delete(s.entries, key)
+	}
+	if s.changed || len(obsolete) > 0 {
+		if err := s.write(); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func (s *snapshots) write() error {
+	var keys []string
+	for key := range s.entries {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return naturalCompare(keys[i], keys[j]) < 0 })
+	var b strings.Builder
+	for i, key := range keys {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		b.WriteString(snapshotHeader + key + "\n" + s.entries[key] + "\n")
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, []byte(b.String()), 0644)
+}
+
+// Serialize v deterministically, for snapshots.
+func serialize(v interface{}) string {
+	s := &serializer{seen: make(map[uintptr]bool)}
+	s.value(reflect.ValueOf(v), "")
+	return s.String()
+}
+
+type serializer struct {
+	strings.Builder
+	seen map[uintptr]bool // Pointers being serialized, to break cycles.
+}
+
+var textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+func (s *serializer) value(v reflect.Value, indent string) {
+	if !v.IsValid() {
+		s.WriteString("nil")
+		return
+	}
+	if v.Type().Implements(textMarshalerType) && v.CanInterface() &&
+		(v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface || !v.IsNil()) {
+		if text, err := v.Interface().(encoding.TextMarshaler).MarshalText(); err == nil {
+			s.WriteString(sprintf("%s(%q)", v.Type(), text))
+			return
+		}
+	}
+	switch v.Kind() {
+	case reflect.Interface:
+		if v.IsNil() {
+			s.WriteString("nil")
+			return
+		}
+		s.value(v.Elem(), indent)
+	case reflect.Ptr:
+		if v.IsNil() {
+			s.WriteString("nil")
+			return
+		}
+		if s.seen[v.Pointer()] {
+			s.WriteString("<cycle>")
+			return
+		}
+		s.seen[v.Pointer()] = true
+		defer delete(s.seen, v.Pointer())
+		s.WriteString("&")
+		s.value(v.Elem(), indent)
+	case reflect.Bool:
+		s.WriteString(sprint(v.Bool()))
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		s.WriteString(sprint(v.Int()))
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		s.WriteString(sprint(v.Uint()))
+	case reflect.Float32, reflect.Float64:
+		s.WriteString(sprint(v.Float()))
+	case reflect.Complex64, reflect.Complex128:
+		s.WriteString(sprint(v.Complex()))
+	case reflect.String:
+		s.WriteString(sprintf("%q", v.String()))
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			s.WriteString("nil")
+			return
+		}
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			p := make([]byte, v.Len())
+			reflect.Copy(reflect.ValueOf(p), v)
+			s.WriteString(sprintf("%s(%q)", v.Type(), p))
+			return
+		}
+		s.list(v.Type(), v.Len(), indent, func(i int) { s.value(v.Index(i), indent+"\t") })
+	case reflect.Map:
+		if v.IsNil() {
+			s.WriteString("nil")
+			return
+		}
+		keys := v.MapKeys()
+		sort.SliceStable(keys, func(i, j int) bool { return compareKeys(keys[i], keys[j]) < 0 })
+		s.list(v.Type(), len(keys), indent, func(i int) {
+			s.value(keys[i], indent+"\t")
+			s.WriteString(": ")
+			s.value(v.MapIndex(keys[i]), indent+"\t")
+		})
+	case reflect.Struct:
+		s.list(v.Type(), v.NumField(), indent, func(i int) {
+			s.WriteString(v.Type().Field(i).Name + ": ")
+			s.value(v.Field(i), indent+"\t")
+		})
+	default:
+		s.WriteString(sprint(v.Type()))
+	}
+}
+
+// Write n items of a value of type typ, one per line.
+func (s *serializer) list(typ reflect.Type, n int, indent string, item func(i int)) {
+	if typ.Kind() != reflect.Struct || typ.Name() != "" {
+		s.WriteString(typ.String())
+	}
+	if n == 0 {
+		s.WriteString("{}")
+		return
+	}
+	s.WriteString("{\n")
+	for i := 0; i < n; i++ {
+		s.WriteString(indent + "\t")
+		item(i)
+		s.WriteString(",\n")
+	}
+	s.WriteString(indent + "}")
+}
@@ -0,0 +1,200 @@
+package table
+
+/*  Filename:    snapshot_test.go
+ *  Description: For testing snapshot.go
+ */
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type serialized struct {
+	Name  string
+	tags  map[string]int
+	Next  *serialized
+	Data  []byte
+	Empty []int
+	Any   interface{}
+}
+
+type serializeTest struct {
+	v   interface{}
+	out string
+}
+
+func (test serializeTest) Test(t T) {
+	if out := serialize(test.v); out != test.out {
+		t.Errorf("serialize(%#v) =>\n%s\nwant\n%s", test.v, out, test.out)
+	}
+}
+
+var cyclic = &serialized{Name: "loop"}
+
+func init() { cyclic.Next = cyclic }
+
+var serializeTests = []serializeTest{
+	{nil, "nil"},
+	{3, "3"},
+	{-2.5, "-2.5"},
+	{"a\nb", `"a\nb"`},
+	{[]int{}, "[]int{}"},
+	{[]string{"x", "y"}, "[]string{\n\t\"x\",\n\t\"y\",\n}"},
+	{map[string]bool{"b 10": true, "b 9": false}, "map[string]bool{\n\t\"b 9\": false,\n\t\"b 10\": true,\n}"},
+	{time.Date(2011, 12, 8, 10, 10, 58, 0, time.UTC), `time.Time("2011-12-08T10:10:58Z")`},
+	{serialized{Name: "a", tags: map[string]int{"x": 1}, Data: []byte("hi"), Any: []int{1}}, "" +
+		"table.serialized{\n" +
+		"\tName: \"a\",\n" +
+		"\ttags: map[string]int{\n" +
+		"\t\t\"x\": 1,\n" +
+		"\t},\n" +
+		"\tNext: nil,\n" +
+		"\tData: []uint8(\"hi\"),\n" +
+		"\tEmpty: nil,\n" +
+		"\tAny: []int{\n" +
+		"\t\t1,\n" +
+		"\t},\n" +
+		"}"},
+	{cyclic, "&table.serialized{\n\tName: \"loop\",\n\ttags: nil,\n\tNext: <cycle>,\n\tData: nil,\n\tEmpty: nil,\n\tAny: nil,\n}"},
+	{struct{ F func() }{}, "{\n\tF: func(),\n}"},
+}
+
+func TestSerialize(t *testing.T) {
+	for i, test := range serializeTests {
+		elementTest(subT(sprintf("serialize %d", i), t), test)
+	}
+}
+
+// Takes snapshots of its values.
+type snapElem []interface{}
+
+func (test snapElem) Test(t T) {
+	for _, v := range test {
+		Snapshot(t, v)
+	}
+}
+
+func snapshotRun(path string, updating bool, table map[string]snapElem) *fauxT {
+	defer func(u bool) { *update = u }(*update)
+	*update = updating
+	return fauxTest("snap", func(t T) { testWith(t, table, SnapshotFile(path)) })
+}
+
+func TestSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshots", "x.snap")
+	ft := snapshotRun(path, false, map[string]snapElem{"a": {1}})
+	if !ft.logLike(`^snap: a: no snapshot "a" in .*x.snap \(run with -table.update to create it\)\n1$`) {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+
+	table := map[string]snapElem{"a": {1, "two"}, "b": {[]int{3}}}
+	if ft := snapshotRun(path, true, table); ft.failed || len(ft.log) > 0 {
+		t.Fatalf("update failed: %v", ft.log)
+	}
+	p, _ := os.ReadFile(path)
+	want := "--- a\n1\n\n--- a #2\n\"two\"\n\n--- b\n[]int{\n\t3,\n}\n"
+	if string(p) != want {
+		t.Errorf("snapshot file %q\nwant %q", p, want)
+	}
+	if ft := snapshotRun(path, false, table); ft.failed || len(ft.log) > 0 {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+
+	ft = snapshotRun(path, false, map[string]snapElem{"b": {[]int{3, 4}}})
+	wantLog := []string{
+		"snap: b: snapshot \"b\" changed (run with -table.update to accept it)\n  ...\n  \t3,\n+ \t4,\n  }",
+		`snap: obsolete snapshots in ` + path + ` (run with -table.update to remove them): ["a" "a #2"]`,
+	}
+	if sprint(ft.log) != sprint(wantLog) || !ft.failed {
+		t.Errorf("unexpected log %q", ft.log)
+	}
+
+	snapshotRun(path, true, map[string]snapElem{"b": {4}})
+	if p, _ := os.ReadFile(path); string(p) != "--- b\n4\n" {
+		t.Errorf("snapshot file %q", p)
+	}
+}
+
+func TestSnapshotErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bad.snap")
+	os.WriteFile(path, []byte("oops\n"), 0644)
+	ft := snapshotRun(path, false, map[string]snapElem{"a": {1}})
+	if !ft.logLike(`bad.snap:1: entry without a header$`) {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+	ft = fauxTest("snap", func(t T) { Snapshot(t, 1) })
+	if !ft.logLike(`^snap: can't take a snapshot outside of a table element$`) {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+	ft = new(fauxT)
+	Snapshot(ft, 1)
+	if !ft.logLike(`can't take a snapshot with \*table.fauxT`) {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+}
+
+func TestSnapshotPath(t *testing.T) {
+	if p := snapshotPath(t); p != filepath.Join("testdata", "snapshots", "TestSnapshotPath.snap") {
+		t.Errorf("unexpected path %s", p)
+	}
+	if p := snapshotPath(new(fauxT)); p != filepath.Join("testdata", "snapshots", "table.snap") {
+		t.Errorf("unexpected path %s", p)
+	}
+}
+
+// Failed elements keep their snapshots, even when updating.
+type failSnapElem bool
+
+func (test failSnapElem) Test(t T) {
+	if test {
+		t.Error("failed before its snapshot")
+		return
+	}
+	Snapshot(t, 1)
+}
+
+func TestSnapshotFailedKept(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "x.snap")
+	snapshotRun(path, true, map[string]snapElem{"a": {1}, "b": {2}})
+	defer func(u bool) { *update = u }(*update)
+	*update = true
+	fauxTest("snap", func(t T) {
+		testWith(t, map[string]failSnapElem{"a": true, "c": false}, SnapshotFile(path))
+	})
+	if p, _ := os.ReadFile(path); string(p) != "--- a\n1\n\n--- c\n1\n" {
+		t.Errorf("snapshot file %q", p)
+	}
+}
+
+// A fauxT with a name and cleanup functions, like *testing.T.
+type cleanupT struct {
+	*fauxT
+	name    string
+	cleanup []func()
+}
+
+func (t *cleanupT) Name() string      { return t.name }
+func (t *cleanupT) Cleanup(fn func()) { t.cleanup = append(t.cleanup, fn) }
+
+func TestSnapshotShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "x.snap")
+	defer func(u bool) { *update = u }(*update)
+	*update = true
+	ct := &cleanupT{fauxT: new(fauxT), name: "TestX"}
+	testWith(ct, []snapElem{{1}}, SnapshotFile(path))
+	testWith(ct, []snapElem{{2}}, SnapshotFile(path))
+	testWith(ct, []runElem{""}, SnapshotFile(path))
+	if _, err := os.Stat(path); err == nil {
+		t.Errorf("snapshots written before the test finished")
+	}
+	if len(ct.cleanup) != 1 {
+		t.Fatalf("%d cleanup functions", len(ct.cleanup))
+	}
+	ct.cleanup[0]()
+	want := "--- table.snapElem 0\n1\n\n--- table.snapElem 0 #2\n2\n"
+	if p, _ := os.ReadFile(path); string(p) != want || ct.failed {
+		t.Errorf("snapshot file %q (log %v)", p, ct.log)
+	}
+}
@@ -0,0 +1,202 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    spy.go
+ *  Description: Recording calls to test doubles.
+ */
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// A Spy records calls made to a test double (a fake implementation of an
+// interface) and checks them against expectations when the element which
+// created it finishes. Fakes call Record from each method. For example,
+//
+//	type fakeStore struct{ *table.Spy }
+//
+//	func (f fakeStore) Put(k, v string) { f.Record("Put", k, v) }
+//
+//	func (test storeTest) Test(t table.T) {
+//		store := fakeStore{table.NewSpy(t)}
+//		store.Expect("Put", "k", table.Any).Times(2)
+//		test.run(store)
+//	}
+//
+// A Spy is safe for concurrent use.
+type Spy struct {
+	mu      sync.Mutex
+	calls   []Call
+	expect  []*Expectation
+	ordered bool
+}
+
+// A call recorded by a Spy.
+type Call struct {
+	Method string
+	Args   []interface{}
+}
+
+func (c Call) String() string {
+	args := make([]string, len(c.Args))
+	for i, a := range c.Args {
+		switch a := a.(type) {
+		case ArgMatcher:
+			args[i] = "<matcher>"
+		case *regexp.Regexp:
+			args[i] = sprintf("/%v/", a)
+		default:
+			args[i] = sprintf("%#v", a)
+		}
+	}
+	return sprintf("%s(%s)", c.Method, strings.Join(args, ", "))
+}
+
+// Create a Spy whose expectations are checked, and unmet expectations
+// reported to t, after the element being tested by t finishes (see Cleanup).
+func NewSpy(t T) *Spy {
+	s := new(Spy)
+	Cleanup(t, func() { s.Verify(t) })
+	return s
+}
+
+// Record a call to method with args.
+func (s *Spy) Record(method string, args ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, Call{method, args})
+}
+
+// Calls recorded for method, or all calls when method is empty.
+func (s *Spy) Calls(method string) (calls []Call) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.calls {
+		if method == "" || c.Method == method {
+			calls = append(calls, c)
+		}
+	}
+	return
+}
+
+// Matches any argument.
+var Any = ArgMatcher(func(interface{}) bool { return true })
+
+// An ArgMatcher reports whether an argument is expected.
+type ArgMatcher func(arg interface{}) bool
+
+// Expect method to be called once with arguments matching args. Arguments
+// match ArgMatchers, match *regexp.Regexp values when formatted, and otherwise
+// must be reflect.DeepEqual to the value expected. Once a method has an
+// expectation, calls to it matching no expectation fail the element.
+func (s *Spy) Expect(method string, args ...interface{}) *Expectation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := &Expectation{Call{method, args}, 1, 1}
+	s.expect = append(s.expect, e)
+	return e
+}
+
+// Require expectations to be first met in the order they were declared.
+func (s *Spy) InOrder() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ordered = true
+}
+
+// The expected calls of a method.
+type Expectation struct {
+	call     Call
+	min, max int // A negative max means there is no maximum.
+}
+
+// Expect exactly n calls.
+func (e *Expectation) Times(n int) *Expectation { e.min, e.max = n, n; return e }
+
+// Expect n or more calls.
+func (e *Expectation) AtLeast(n int) *Expectation { e.min, e.max = n, -1; return e }
+
+func (e *Expectation) String() string {
+	switch {
+	case e.max < 0:
+		return sprintf("%v at least %d times", e.call, e.min)
+	case e.min == 1:
+		return sprintf("%v once", e.call)
+	}
+	return sprintf("%v %d times", e.call, e.min)
+}
+
+func (e *Expectation) matches(c Call) bool {
+	if c.Method != e.call.Method || len(c.Args) != len(e.call.Args) {
+		return false
+	}
+	for i, want := range e.call.Args {
+		switch want := want.(type) {
+		case ArgMatcher:
+			if !want(c.Args[i]) {
+				return false
+			}
+		case *regexp.Regexp:
+			if mismatch(want, sprint(c.Args[i])) != "" {
+				return false
+			}
+		default:
+			if !reflect.DeepEqual(c.Args[i], want) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Check the recorded calls against the expectations, reporting unmet
+// expectations and unexpected calls to t. NewSpy arranges for Verify to be
+// called when the element finishes.
+func (s *Spy) Verify(t T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expected := make(map[string]bool)
+	for _, e := range s.expect {
+		expected[e.call.Method] = true
+	}
+	counts := make([]int, len(s.expect))
+	first := make([]int, len(s.expect))
+	for i := range first {
+		first[i] = -1
+	}
+	for i, c := range s.calls {
+		matched := false
+		for j, e := range s.expect {
+			if e.matches(c) && (e.max < 0 || counts[j] < e.max) {
+				if counts[j] == 0 {
+					first[j] = i
+				}
+				counts[j]++
+				matched = true
+				break
+			}
+		}
+		if !matched && expected[c.Method] {
+			t.Errorf("unexpected call %v", c)
+		}
+	}
+	last := -1
+	for j, e := range s.expect {
+		if counts[j] < e.min {
+			t.Errorf("expected %v; called %d times", e, counts[j])
+			continue
+		}
+		if s.ordered && first[j] >= 0 {
+			if first[j] < last {
+				t.Errorf("%v called out of order", e.call)
+			}
+			last = first[j]
+		}
+	}
+}
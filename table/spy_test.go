@@ -0,0 +1,91 @@
+package table
+
+/*  Filename:    spy_test.go
+ *  Description: For testing spy.go
+ */
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// A fake key-value store.
+type fakeStore struct{ *Spy }
+
+func (f fakeStore) Get(k string) string { f.Record("Get", k); return "" }
+func (f fakeStore) Put(k, v string)     { f.Record("Put", k, v) }
+
+// Declares expectations on a fakeStore and then uses it.
+type spyElem struct {
+	expect func(*Spy)
+	use    func(fakeStore)
+}
+
+func (test spyElem) Test(t T) {
+	store := fakeStore{NewSpy(t)}
+	test.expect(store.Spy)
+	test.use(store)
+}
+
+func spyTest(expect func(*Spy), use func(fakeStore)) func(T) {
+	return func(t T) { elementTest(t, spyElem{expect, use}) }
+}
+
+var spyTests = []metaTestSimple{
+	{"met", spyTest(
+		func(s *Spy) { s.Expect("Put", "k", "v") },
+		func(f fakeStore) { f.Put("k", "v"); f.Get("k") }), nil},
+	{"not called", spyTest(
+		func(s *Spy) { s.Expect("Put", "k", "v") },
+		func(f fakeStore) {}),
+		[]string{`^simple meta-test: expected Put\("k", "v"\) once; called 0 times$`}},
+	{"unexpected", spyTest(
+		func(s *Spy) { s.Expect("Put", "k", Any) },
+		func(f fakeStore) { f.Put("k", "v"); f.Put("j", "v") }),
+		[]string{`^simple meta-test: unexpected call Put\("j", "v"\)$`}},
+	{"times", spyTest(
+		func(s *Spy) { s.Expect("Get", Any).Times(2) },
+		func(f fakeStore) { f.Get("a") }),
+		[]string{`^simple meta-test: expected Get\(<matcher>\) 2 times; called 1 times$`}},
+	{"at least", spyTest(
+		func(s *Spy) { s.Expect("Get", regexp.MustCompile(`^a`)).AtLeast(1) },
+		func(f fakeStore) { f.Get("a"); f.Get("ab") }), nil},
+	{"at least unmet", spyTest(
+		func(s *Spy) { s.Expect("Get", regexp.MustCompile(`^a`)).AtLeast(2) },
+		func(f fakeStore) { f.Get("a") }),
+		[]string{`^simple meta-test: expected Get\(/\^a/\) at least 2 times; called 1 times$`}},
+	{"matcher", spyTest(
+		func(s *Spy) {
+			s.Expect("Get", ArgMatcher(func(a interface{}) bool { return strings.HasSuffix(a.(string), "z") }))
+		},
+		func(f fakeStore) { f.Get("abc") }),
+		[]string{`^simple meta-test: unexpected call Get\("abc"\)$`, `called 0 times$`}},
+	{"in order", spyTest(
+		func(s *Spy) { s.InOrder(); s.Expect("Put", "k", "v"); s.Expect("Get", "k") },
+		func(f fakeStore) { f.Put("k", "v"); f.Get("k") }), nil},
+	{"out of order", spyTest(
+		func(s *Spy) { s.InOrder(); s.Expect("Put", "k", "v"); s.Expect("Get", "k") },
+		func(f fakeStore) { f.Get("k"); f.Put("k", "v") }),
+		[]string{`^simple meta-test: Get\("k"\) called out of order$`}},
+}
+
+func TestSpy(t *testing.T) {
+	for i, test := range spyTests {
+		elementTest(subT(sprintf("spy %d", i), t), test)
+	}
+}
+
+func TestSpyCalls(t *testing.T) {
+	s := new(Spy)
+	f := fakeStore{s}
+	f.Put("k", "v")
+	f.Get("k")
+	if calls := s.Calls("Get"); !reflect.DeepEqual(calls, []Call{{"Get", []interface{}{"k"}}}) {
+		t.Errorf("unexpected calls %v", calls)
+	}
+	if calls := s.Calls(""); len(calls) != 2 || calls[0].String() != `Put("k", "v")` {
+		t.Errorf("unexpected calls %v", calls)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    stack.go
+ *  Description: Goroutine stacks of panicking elements.
+ */
+
+import (
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+// The directory containing the package's source files.
+var packageDir = func() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}()
+
+// The stack of the calling goroutine without frames belonging to the runtime,
+// reflect, testing, or this package. Call it from a deferred function
+// recovering a panic to see where the panic happened.
+func panicStack() string { return trimStack(string(debug.Stack())) }
+
+// Remove uninteresting frames from a stack formatted by debug.Stack.
+func trimStack(stack string) string {
+	lines := strings.Split(strings.TrimRight(stack, "\n"), "\n")
+	if len(lines) == 0 {
+		return ""
+	}
+	trimmed := lines[:1] // goroutine header
+	for i := 1; i+1 < len(lines); i += 2 {
+		if !boringFrame(lines[i], lines[i+1]) {
+			trimmed = append(trimmed, lines[i], lines[i+1])
+		}
+	}
+	return strings.Join(trimmed, "\n")
+}
+
+// Frames are a function line followed by an indented "file:line +offset" line.
+func boringFrame(fn, loc string) bool {
+	fn = strings.TrimPrefix(fn, "created by ")
+	for _, prefix := range []string{"runtime.", "runtime/debug.", "panic(", "reflect.", "testing."} {
+		if strings.HasPrefix(fn, prefix) {
+			return true
+		}
+	}
+	file := strings.TrimSpace(loc)
+	if i := strings.LastIndex(file, ":"); i >= 0 {
+		file = file[:i]
+	}
+	return filepath.Dir(file) == packageDir && !strings.HasSuffix(file, "_test.go")
+}
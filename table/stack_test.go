@@ -0,0 +1,66 @@
+package table
+
+/*  Filename:    stack_test.go
+ *  Description: For testing stack.go
+ */
+
+import (
+	"strings"
+	"testing"
+)
+
+type stackElem struct{}
+
+func stackPanicker() { panic("deep") }
+
+func (test stackElem) Test(t T) { stackPanicker() }
+
+func TestPanicStack(t *testing.T) {
+	ft := fauxTest("stack", func(t T) { testWith(t, []stackElem{{}}) })
+	if ft.Len() != 1 {
+		t.Fatalf("unexpected log %v", ft.log)
+	}
+	log := ft.IndexString(0)
+	for _, sub := range []string{"panic: deep\ngoroutine ", "table.stackPanicker(", "stack_test.go:16", "table.stackElem.Test("} {
+		stringContains(t, "stack", log, sub)
+	}
+	for _, sub := range []string{"elementTest", "runtime/debug", "reflect.", "panic(", "/table/test.go:"} {
+		stringMissing(t, "stack", log, sub)
+	}
+}
+
+var trimStackTests = []struct{ in, out string }{
+	{"goroutine 1 [running]:\n", "goroutine 1 [running]:"},
+	{`goroutine 1 [running]:
+runtime/debug.Stack()
+	/go/src/runtime/debug/stack.go:26 +0x5e
+main.f()
+	/src/main.go:12 +0x12
+reflect.Value.Call({0x1, 0x2, 0x3}, {0x4, 0x5, 0x6})
+	/go/src/reflect/value.go:368 +0xbc
+testing.tRunner(0xc000007860, 0x5b1e28)
+	/go/src/testing/testing.go:1690 +0xf4
+created by testing.(*T).Run in goroutine 1
+	/go/src/testing/testing.go:1743 +0x390
+`, "goroutine 1 [running]:\nmain.f()\n\t/src/main.go:12 +0x12"},
+}
+
+func TestTrimStack(t *testing.T) {
+	for i, test := range trimStackTests {
+		if out := trimStack(test.in); out != test.out {
+			t.Errorf("trimStack %d: %q != %q", i, out, test.out)
+		}
+	}
+}
+
+func TestBoringFrame(t *testing.T) {
+	if !boringFrame("table.elementTest()", "\t"+packageDir+"/test.go:10 +0x1") {
+		t.Error("package frame not trimmed")
+	}
+	if boringFrame("table.f()", "\t"+packageDir+"/test_test.go:10 +0x1") {
+		t.Error("test file frame trimmed")
+	}
+	if !strings.HasSuffix(packageDir, "table") {
+		t.Errorf("unexpected package directory %q", packageDir)
+	}
+}
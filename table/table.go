@@ -35,6 +35,13 @@ func validValue(t *testingT, v reflect.Value, zero reflect.Value) reflect.Value
 	return v
 }
 
+// Report an error returned by a range callback, unless it is errStop.
+func rangeError(t *testingT, out reflect.Value) {
+	if err := out.Interface(); err != errStop {
+		t.Error(err)
+	}
+}
+
 // Iterate over a range of values, issuing a callback for each one. The callback
 // fn is expected to take two arguments (index/key, value pair) and return an
 // error.
@@ -59,13 +66,17 @@ func doRange(t *testingT, v reflect.Value, fn interface{}) {
 			}
 			out = fnval.Call([]reflect.Value{ival, arg})[0]
 			if !out.IsNil() {
-				t.Error(out.Interface())
+				rangeError(t, out)
 				break
 			}
 
 		}
 	case reflect.Map:
-		for _, kval := range v.MapKeys() {
+		keys, err := sortedKeys(v, t.cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, kval := range keys {
 			vval := v.MapIndex(kval)
 			arg := validValue(t.sub(sprintf("index %v", kval.Interface())), vval, zero)
 			if !arg.IsValid() {
@@ -73,10 +84,42 @@ func doRange(t *testingT, v reflect.Value, fn interface{}) {
 			}
 			out = fnval.Call([]reflect.Value{kval, arg})[0]
 			if !out.IsNil() {
-				t.Error(out.Interface())
+				rangeError(t, out)
 				break
 			}
 		}
+	case reflect.Func:
+		switch {
+		case v.Type().CanSeq2():
+			for kval, vval := range v.Seq2() {
+				arg := validValue(t.sub(sprintf("key %v", kval.Interface())), vval, zero)
+				if !arg.IsValid() {
+					continue
+				}
+				out = fnval.Call([]reflect.Value{kval, arg})[0]
+				if !out.IsNil() {
+					rangeError(t, out)
+					break
+				}
+			}
+		case v.Type().CanSeq():
+			i := 0
+			for vval := range v.Seq() {
+				ival := reflect.ValueOf(i)
+				arg := validValue(t.sub(sprintf("value %d", i)), vval, zero)
+				i++
+				if !arg.IsValid() {
+					continue
+				}
+				out = fnval.Call([]reflect.Value{ival, arg})[0]
+				if !out.IsNil() {
+					rangeError(t, out)
+					break
+				}
+			}
+		default:
+			t.Fatalf("unacceptable type for range %v", v.Type())
+		}
 	case reflect.Chan:
 		var vval reflect.Value
 		var ok bool
@@ -91,7 +134,7 @@ func doRange(t *testingT, v reflect.Value, fn interface{}) {
 			}
 			out = fnval.Call([]reflect.Value{ival, arg})[0]
 			if !out.IsNil() {
-				t.Error(out.Interface())
+				rangeError(t, out)
 				break
 			}
 		}
@@ -100,13 +143,22 @@ func doRange(t *testingT, v reflect.Value, fn interface{}) {
 	}
 }
 
-func testMap(t *testingT, v reflect.Value) {
-	doRange(t.sub("map"), v, func(k, v interface{}) error {
-		sub := t.sub(sprint(k))
-		if test, err := mustElement(sub, v); err == nil {
-			elementTest(sub, test)
+// Test each value in a map table, in order of their keys.
+func testMap(r *runner, v reflect.Value) {
+	i := 0
+	doRange(r.t.sub("map"), v, func(k, v interface{}) error {
+		v, loc, name := unwrapRow(v)
+		if loc == "" {
+			loc = r.cfg.locs.atKey(k)
+		}
+		if name == "" {
+			name = keyName(k, i)
 		}
-		return nil
+		i++
+		if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			v = nil
+		}
+		return r.add(job{k, r.name(k, v, name), loc, v})
 	})
 }
 
@@ -126,16 +178,61 @@ func stringifyIndex(i int, v interface{}) string {
 }
 
 // Test each value in a slice table.
-func testSlice(t *testingT, v reflect.Value) {
-	doRange(t.sub("slice"), v, func(i int, elem interface{}) error {
-		sub := t.sub(stringifyIndex(i, elem))
-		if test, err := mustElement(sub, elem); err == nil {
-			elementTest(sub, test)
+func testSlice(r *runner, v reflect.Value) {
+	doRange(r.t.sub("slice"), v, func(i int, elem interface{}) error {
+		elem, loc, name := unwrapRow(elem)
+		if loc == "" {
+			loc = r.cfg.locs.atIndex(i)
+		}
+		if name == "" {
+			name = stringifyIndex(i, elem)
 		}
-		return nil
+		return r.add(job{i, r.name(i, elem, name), loc, elem})
 	})
 }
 
+// Test each value yielded by an iterator table (an iter.Seq or iter.Seq2).
+// Values of an iter.Seq2 are named by their keys. Returns the number of values
+// yielded.
+func testSeq(r *runner, v reflect.Value) (n int) {
+	keyed := v.Type().CanSeq2()
+	doRange(r.t.sub("iterator"), v, func(k, elem interface{}) error {
+		elem, loc, name := unwrapRow(elem)
+		if name == "" && keyed {
+			name = keyName(k, n)
+		} else if name == "" {
+			name = stringifyIndex(n, elem)
+		}
+		n++
+		return r.add(job{k, r.name(k, elem, name), loc, elem})
+	})
+	return
+}
+
+// The number of elements in a table; -1 for iterators.
+func tableLen(tab reflect.Value) int {
+	if tab.Kind() == reflect.Func {
+		return -1
+	}
+	return tab.Len()
+}
+
+// Test the elements of a validated table.
+func rangeTable(t *testingT, r *runner, tab reflect.Value) {
+	switch tab.Kind() {
+	case reflect.Slice:
+		testSlice(r, tab)
+	case reflect.Map:
+		testMap(r, tab)
+	case reflect.Func:
+		if testSeq(r, tab) == 0 {
+			t.Fatal("empty table")
+		}
+	default:
+		t.Fatalf("unexpected table kind %v", tab.Kind())
+	}
+}
+
 // Detect a value's reflect.Kind. Return the reflect.Value as well for good measure.
 func kind(x interface{}) (reflect.Value, reflect.Kind) { v := reflect.ValueOf(x); return v, v.Kind() }
 
@@ -146,8 +243,16 @@ func validateTable(t *testingT, table interface{}) (tab reflect.Value, k reflect
 		t.Fatal("table is invalid")
 	case reflect.Slice, reflect.Map: // Allow chan?
 		break
+	case reflect.Func:
+		switch {
+		case !tab.Type().CanSeq() && !tab.Type().CanSeq2():
+			t.Fatalf("table %v is not an iterator", tab.Type())
+		case tab.IsNil():
+			t.Fatal("nil iterator table")
+		}
+		return
 	default:
-		t.Fatalf("table %v is not a slice", tab.Type())
+		t.Fatalf("table %v is not a slice, map or iterator", tab.Type())
 	}
 
 	// A table can't be empty.
@@ -159,23 +264,57 @@ func validateTable(t *testingT, table interface{}) (tab reflect.Value, k reflect
 
 func testHelper(t *testingT, table interface{}) {
 	tinternal := subT("internal table.Test", t)
-	val, k := validateTable(tinternal.sub("table validation"), table)
-	switch k {
-	case reflect.Slice:
-		testSlice(t, val)
-	case reflect.Map:
-		testMap(t, val)
-	default:
-		tinternal.Fatalf("unexpected table kind %v", k)
+	val, _ := validateTable(tinternal.sub("table validation"), unwrapTable(table))
+	r := newRunner(t)
+	r.total = tableLen(val)
+	rangeTable(tinternal, r, val)
+	r.finish()
+}
+
+// Run a table test with the given options on any T.
+func testWith(t T, table interface{}, opts ...Option) {
+	root := subT("", t)
+	root.cfg = newConfig(opts)
+	root.cfg.locs = findLocations(root.cfg.file, root.cfg.line, root.cfg.arg, "Test")
+	if root.name != "" {
+		root.cfg.prefix = root.name + nameSep
+	}
+	if root.cfg.file != "" {
+		root.cfg.site = sprintf("%s:%d", root.cfg.file, root.cfg.line)
 	}
+	if root.cfg.baselineFile != "" {
+		root.cfg.baseline = openSnapshots(root, root.cfg.baselineFile, "baseline entries")
+	}
+	testHelper(root, table)
 }
 
-// A table driven test. The table must be a slice of values all implementing
-// Element. But, not all elements need be of the same type. And furthermore,
-// the slice's element type does not need to satisfy Element. For example, a
-// slice v of type []interface{} can be a valid table if all its elements
-// satisfy Element.
+// A table driven test. The table must be a slice or map of values all
+// implementing Element. But, not all elements need be of the same type. And
+// furthermore, the slice's element type does not need to satisfy Element. For
+// example, a slice v of type []interface{} can be a valid table if all its
+// elements satisfy Element. Elements implementing ElementTable are tables
+// themselves, tested in place of the element. The table itself may implement
+// ElementTable too (e.g. a MatrixTable).
+//
+// Messages logged by an element are prefixed with the file and line declaring
+// the element when the table is a composite literal (or a variable last
+// assigned one in the calling function, or declared as one at package level in
+// the calling file), or when the element is wrapped with Row.
 //
-// A feasible future enhancement would be to allow map tables. Possibly chan
-// tables.
-func Test(t *testing.T, table interface{}) { testHelper(subT("", t), table) }
+// Options configure only this call, so tables in the same package may behave
+// differently. For example,
+//
+//	table.Test(t, tests, table.Parallel(4), table.Timeout(time.Second), table.FailFast())
+//
+// tests four elements at a time, fails elements taking longer than a second,
+// and stops after the first failed element.
+//
+// Map tables are tested in order of their keys (see KeyOrder), and their
+// elements named by key. Nil elements, in map or slice tables, fail.
+//
+// Iterator tables (iter.Seq or iter.Seq2 values) are tested as they yield
+// elements. Elements yielded by an iter.Seq2 are named by their keys.
+// Iteration stops when no more elements will be tested (e.g. with FailFast).
+func Test(t *testing.T, table interface{}, opts ...Option) {
+	testWith(t, table, append([]Option{callSite(1)}, opts...)...)
+}
@@ -0,0 +1,140 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*  Filename:    exec.go
+ *  Description: Table driven testing of commands.
+ */
+
+/*
+Package tableexec tests command line programs with tables of invocations. Each
+Case describes the arguments, environment, standard input and files given to a
+command, along with its expected exit code and output.
+
+	tableexec.Test(t, "./mytool", []tableexec.Case{
+		{Args: []string{"-version"}, Stdout: table.Regexp(`^mytool \d+`)},
+		{Args: []string{"cat", "in.txt"}, Files: map[string]string{"in.txt": "hi"}, Stdout: table.Exact("hi")},
+		{Args: []string{"-bogus"}, Exit: 2, Stderr: table.Regexp("flag provided but not defined")},
+	}, table.Timeout(10*time.Second))
+
+Every case runs hermetically in a new temporary directory, with an
+environment containing only PATH, HOME (the temporary directory) and the
+case's Env. Cases are tested with package table, so they are named, timed out
+and protected from panics like any other table element.
+*/
+package tableexec
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bmatsuo/go-table/table"
+)
+
+// An invocation of a command and the result expected from it.
+type Case struct {
+	Name  string            // Name of the case; defaults to the arguments.
+	Args  []string          // Arguments, not including the command itself.
+	Env   []string          // Additional "key=value" environment variables.
+	Stdin string            // Standard input.
+	Files map[string]string // Files created in the working directory, by relative path.
+
+	Exit   int           // Expected exit code.
+	Stdout table.Matcher // Checks standard output, when non-nil.
+	Stderr table.Matcher // Checks standard error, when non-nil.
+}
+
+// A Case bound to the command it tests.
+type element struct {
+	Case
+	path string
+}
+
+func (e element) String() string {
+	if e.Name != "" {
+		return e.Name
+	}
+	return strings.Join(append([]string{filepath.Base(e.path)}, e.Args...), " ")
+}
+
+func (e element) Test(t table.T) {
+	dir, err := os.MkdirTemp("", "tableexec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, content := range e.Files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(table.Context(t), e.path, e.Args...)
+	cmd.Dir = dir
+	cmd.Env = append([]string{"PATH=" + os.Getenv("PATH"), "HOME=" + dir}, e.Env...)
+	cmd.Stdin = strings.NewReader(e.Stdin)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	code := 0
+	if err := cmd.Run(); err != nil {
+		var exit *exec.ExitError
+		if !errors.As(err, &exit) {
+			t.Fatal(err)
+		}
+		code = exit.ExitCode()
+	}
+	if code != e.Exit {
+		t.Errorf("exit code %d, want %d; stderr %q", code, e.Exit, stderr.Bytes())
+	}
+	if e.Stdout != nil {
+		if err := e.Stdout.Match(stdout.Bytes()); err != nil {
+			t.Errorf("stdout: %v", err)
+		}
+	}
+	if e.Stderr != nil {
+		if err := e.Stderr.Match(stderr.Bytes()); err != nil {
+			t.Errorf("stderr: %v", err)
+		}
+	}
+}
+
+// Bind cases to the command at path, returning a table for table.Test. A
+// relative path is made absolute, because cases don't run in the current
+// directory; a path without separators is looked up in PATH.
+func Table(path string, cases []Case) ([]table.Element, error) {
+	var err error
+	if strings.ContainsRune(path, filepath.Separator) {
+		path, err = filepath.Abs(path)
+	} else {
+		path, err = exec.LookPath(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	elems := make([]table.Element, len(cases))
+	for i, c := range cases {
+		elems[i] = element{c, path}
+	}
+	return elems, nil
+}
+
+// Test the command at path with a table of cases. Use the table.Timeout option
+// to bound the time each case may run; commands still running when their case
+// times out are killed.
+func Test(t *testing.T, path string, cases []Case, opts ...table.Option) {
+	elems, err := Table(path, cases)
+	if err != nil {
+		t.Fatal(err)
+	}
+	table.Test(t, elems, append([]table.Option{table.CallSite(1, 2)}, opts...)...)
+}
@@ -0,0 +1,108 @@
+package tableexec
+
+/*  Filename:    exec_test.go
+ *  Description: For testing exec.go
+ */
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/bmatsuo/go-table/table"
+)
+
+// The test binary doubles as the command under test when helperEnv is set.
+const helperEnv = "TABLEEXEC_HELPER=1"
+
+func TestMain(m *testing.M) {
+	if os.Getenv("TABLEEXEC_HELPER") == "1" {
+		os.Exit(helper(os.Args[1:]))
+	}
+	os.Exit(m.Run())
+}
+
+func helper(args []string) int {
+	switch args[0] {
+	case "echo":
+		fmt.Println(args[1])
+	case "cat":
+		p, err := os.ReadFile(args[1])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		os.Stdout.Write(p)
+	case "stdin":
+		io.Copy(os.Stdout, os.Stdin)
+	case "env":
+		fmt.Print(os.Getenv(args[1]))
+	case "pwd":
+		wd, _ := os.Getwd()
+		fmt.Print(wd == os.Getenv("HOME"))
+	case "exit":
+		n, _ := strconv.Atoi(args[1])
+		fmt.Fprintln(os.Stderr, "exiting")
+		return n
+	}
+	return 0
+}
+
+func TestCommand(t *testing.T) {
+	Test(t, os.Args[0], []Case{
+		{Args: []string{"echo", "hi"}, Env: []string{helperEnv}, Stdout: table.Exact("hi\n")},
+		{Args: []string{"cat", "a/b.txt"}, Env: []string{helperEnv},
+			Files: map[string]string{"a/b.txt": "fixture"}, Stdout: table.Exact("fixture")},
+		{Args: []string{"cat", "a/b.txt"}, Env: []string{helperEnv}, Exit: 1,
+			Stderr: table.Regexp("no such file")},
+		{Args: []string{"stdin"}, Env: []string{helperEnv}, Stdin: "piped", Stdout: table.Exact("piped")},
+		{Args: []string{"env", "FOO"}, Env: []string{helperEnv, "FOO=bar"}, Stdout: table.Exact("bar")},
+		{Args: []string{"env", "GOPATH"}, Env: []string{helperEnv}, Stdout: table.Exact("")},
+		{Args: []string{"pwd"}, Env: []string{helperEnv}, Stdout: table.Exact("true")},
+		{Name: "exit", Args: []string{"exit", "3"}, Env: []string{helperEnv}, Exit: 3,
+			Stderr: table.Exact("exiting\n")},
+	})
+}
+
+// Records whether a case failed and what it logged.
+type recordT struct {
+	failed bool
+	log    []string
+}
+
+func (t *recordT) Fail()                                     { t.failed = true }
+func (t *recordT) FailNow()                                  { t.Fail() }
+func (t *recordT) Failed() bool                              { return t.failed }
+func (t *recordT) Log(args ...interface{})                   { t.log = append(t.log, fmt.Sprint(args...)) }
+func (t *recordT) Error(args ...interface{})                 { t.Log(args...); t.Fail() }
+func (t *recordT) Fatal(args ...interface{})                 { t.Error(args...) }
+func (t *recordT) Logf(format string, args ...interface{})   { t.Log(fmt.Sprintf(format, args...)) }
+func (t *recordT) Errorf(format string, args ...interface{}) { t.Error(fmt.Sprintf(format, args...)) }
+func (t *recordT) Fatalf(format string, args ...interface{}) { t.Error(fmt.Sprintf(format, args...)) }
+
+func TestFailures(t *testing.T) {
+	elems, err := Table(os.Args[0], []Case{
+		{Args: []string{"exit", "2"}, Env: []string{helperEnv}},
+		{Args: []string{"echo", "hi"}, Env: []string{helperEnv}, Stdout: table.Exact("bye\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		`exit code 2, want 0; stderr "exiting\n"`,
+		`stdout: "hi\n", want "bye\n"`,
+	}
+	for i, elem := range elems {
+		rec := new(recordT)
+		elem.Test(rec)
+		if !rec.failed || len(rec.log) != 1 || rec.log[0] != want[i] {
+			t.Errorf("case %d: unexpected log %q", i, rec.log)
+		}
+	}
+	if s := fmt.Sprint(elems[1]); s != filepath.Base(os.Args[0])+" echo hi" {
+		t.Errorf("unexpected name %q", s)
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*  Filename:    http.go
+ *  Description: Table driven testing of HTTP handlers.
+ */
+
+/*
+Package tablehttp tests net/http handlers with tables of requests. Each Case
+describes a request and the response expected from the handler.
+
+	tablehttp.Test(t, handler, []tablehttp.Case{
+		{Path: "/hello", Status: 200, Body: table.Exact("hello")},
+		{Method: "POST", Path: "/login", Session: "alice", ReqBody: "user=alice", Status: 303},
+		{Path: "/whoami", Session: "alice", Body: table.Regexp("alice")},
+	})
+
+Cases are tested with package table, so they are named, located and protected
+from panics like any other table element.
+*/
+package tablehttp
+
+import (
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bmatsuo/go-table/table"
+)
+
+// A request made to a handler and the response expected from it.
+type Case struct {
+	Name    string      // Name of the case; defaults to the method and path.
+	Session string      // Cases with the same non-empty Session share cookies.
+	Method  string      // Request method; defaults to GET.
+	Path    string      // Request URI, including any query.
+	Header  http.Header // Request headers.
+	ReqBody string      // Request body.
+
+	Status     int           // Expected status code; zero accepts any status.
+	WantHeader http.Header   // Expected response headers; others are ignored.
+	Body       table.Matcher // Checks the response body, when non-nil.
+}
+
+func (c Case) method() string {
+	if c.Method == "" {
+		return "GET"
+	}
+	return c.Method
+}
+
+// A Case bound to the handler it tests, and to the cookie jar of its session
+// (nil without a Session).
+type element struct {
+	Case
+	h   http.Handler
+	jar http.CookieJar
+}
+
+func (e element) String() string {
+	if e.Name != "" {
+		return e.Name
+	}
+	return e.method() + " " + e.Path
+}
+
+func (e element) Test(t table.T) {
+	req := httptest.NewRequest(e.method(), e.Path, strings.NewReader(e.ReqBody))
+	for k, vs := range e.Header {
+		req.Header[k] = vs
+	}
+	u := *req.URL
+	if u.Scheme == "" {
+		u.Scheme = "http"
+	}
+	if u.Host == "" {
+		u.Host = req.Host
+	}
+	if e.jar != nil {
+		for _, c := range e.jar.Cookies(&u) {
+			req.AddCookie(c)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	e.h.ServeHTTP(rec, req)
+	resp := rec.Result()
+	defer resp.Body.Close()
+	if e.jar != nil {
+		e.jar.SetCookies(&u, resp.Cookies())
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("reading body: %v", err)
+	}
+	if e.Status != 0 && resp.StatusCode != e.Status {
+		t.Errorf("status %d, want %d; body %q", resp.StatusCode, e.Status, body)
+	}
+	for k, want := range e.WantHeader {
+		if got := resp.Header.Values(k); !reflect.DeepEqual(got, want) {
+			t.Errorf("header %s: %q, want %q", k, got, want)
+		}
+	}
+	if e.Body != nil {
+		if err := e.Body.Match(body); err != nil {
+			t.Errorf("body: %v", err)
+		}
+	}
+}
+
+// Bind cases to h, returning a table for table.Test. Cases sharing a Session
+// are chained through a cookie jar, so they must be tested in order (i.e.
+// without the Parallel or Shuffle options). Cases of different sessions may be
+// tested in parallel.
+func Table(h http.Handler, cases []Case) []table.Element {
+	jars := make(map[string]http.CookieJar)
+	elems := make([]table.Element, len(cases))
+	for i, c := range cases {
+		if c.Session != "" && jars[c.Session] == nil {
+			jars[c.Session], _ = cookiejar.New(nil)
+		}
+		elems[i] = element{c, h, jars[c.Session]}
+	}
+	return elems
+}
+
+// Test h with a table of cases.
+func Test(t *testing.T, h http.Handler, cases []Case, opts ...table.Option) {
+	table.Test(t, Table(h, cases), append([]table.Option{table.CallSite(1, 2)}, opts...)...)
+}
@@ -0,0 +1,142 @@
+package tablehttp
+
+/*  Filename:    http_test.go
+ *  Description: For testing http.go
+ */
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"testing"
+
+	"github.com/bmatsuo/go-table/table"
+)
+
+func testHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		fmt.Fprint(w, "hello")
+	})
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"method": %q, "accept": %q}`, r.Method, r.Header.Get("Accept"))
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "user", Value: r.FormValue("user"), Path: "/"})
+		http.Redirect(w, r, "/whoami", http.StatusSeeOther)
+	})
+	mux.HandleFunc("/whoami", func(w http.ResponseWriter, r *http.Request) {
+		c, err := r.Cookie("user")
+		if err != nil {
+			http.Error(w, "anonymous", http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, c.Value)
+	})
+	mux.HandleFunc("/api/login", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "token", Value: "t1", Path: "/api"})
+		http.SetCookie(w, &http.Cookie{Name: "scratch", Value: "s1"}) // Default path /api.
+	})
+	cookies := func(w http.ResponseWriter, r *http.Request) {
+		for _, c := range r.Cookies() {
+			fmt.Fprintf(w, "%s=%s;", c.Name, c.Value)
+		}
+	}
+	mux.HandleFunc("/api/me", cookies)
+	mux.HandleFunc("/me", cookies)
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) { panic("handler panic") })
+	return mux
+}
+
+func TestHandler(t *testing.T) {
+	Test(t, testHandler(), []Case{
+		{Path: "/hello", Status: 200, Body: table.Exact("hello"),
+			WantHeader: http.Header{"Content-Type": {"text/plain"}}},
+		{Method: "PUT", Path: "/json", Header: http.Header{"Accept": {"text/json"}},
+			Body: table.JSONEqual(`{"accept": "text/json", "method": "PUT"}`)},
+		{Path: "/whoami", Session: "alice", Status: 401},
+		{Method: "POST", Path: "/login", Session: "alice", ReqBody: "user=alice",
+			Header: http.Header{"Content-Type": {"application/x-www-form-urlencoded"}}, Status: 303},
+		{Path: "/whoami", Session: "alice", Status: 200, Body: table.Exact("alice")},
+		{Path: "/whoami", Session: "bob", Status: 401},
+		{Path: "/whoami", Status: 401, Body: table.Regexp(`^anonymous`)},
+		{Path: "/api/login", Session: "carol"},
+		{Path: "/api/me", Session: "carol", Body: table.Exact("token=t1;scratch=s1;")},
+		{Path: "/me", Session: "carol", Body: table.Exact("")},
+	})
+}
+
+// Cases of distinct sessions tested in parallel.
+func TestParallelSessions(t *testing.T) {
+	var cases []Case
+	for i := 0; i < 100; i++ {
+		user := fmt.Sprint("user", i)
+		cases = append(cases,
+			Case{Name: user + " login", Method: "POST", Path: "/login?user=" + user, Session: user, Status: 303},
+			Case{Name: user + " anonymous", Path: "/whoami", Session: user + "-anon", Status: 401})
+	}
+	Test(t, testHandler(), cases, table.Parallel(16))
+}
+
+// Records messages logged by failing cases.
+type recordT struct {
+	failed bool
+	log    []string
+}
+
+func (t *recordT) Fail()                                     { t.failed = true }
+func (t *recordT) FailNow()                                  { t.Fail() }
+func (t *recordT) Failed() bool                              { return t.failed }
+func (t *recordT) Log(args ...interface{})                   { t.log = append(t.log, fmt.Sprint(args...)) }
+func (t *recordT) Error(args ...interface{})                 { t.Log(args...); t.Fail() }
+func (t *recordT) Fatal(args ...interface{})                 { t.Error(args...) }
+func (t *recordT) Logf(format string, args ...interface{})   { t.Log(fmt.Sprintf(format, args...)) }
+func (t *recordT) Errorf(format string, args ...interface{}) { t.Error(fmt.Sprintf(format, args...)) }
+func (t *recordT) Fatalf(format string, args ...interface{}) { t.Error(fmt.Sprintf(format, args...)) }
+
+type failureTest struct {
+	c    Case
+	errs []string
+}
+
+func (test failureTest) Test(t table.T) {
+	rec := new(recordT)
+	test.c.Name = "case"
+	Table(testHandler(), []Case{test.c})[0].Test(rec)
+	if !rec.failed {
+		t.Errorf("case passed unexpectedly")
+	}
+	if len(rec.log) != len(test.errs) {
+		t.Fatalf("unexpected log %q", rec.log)
+	}
+	for i, patt := range test.errs {
+		if !regexp.MustCompile(patt).MatchString(rec.log[i]) {
+			t.Errorf("log line %d %q doesn't match %q", i, rec.log[i], patt)
+		}
+	}
+}
+
+func TestFailures(t *testing.T) {
+	table.Test(t, []failureTest{
+		{Case{Path: "/hello", Status: 201}, []string{`^status 200, want 201; body "hello"$`}},
+		{Case{Path: "/hello", WantHeader: http.Header{"X-Missing": {"1"}}}, []string{`^header X-Missing: \[\], want \["1"\]$`}},
+		{Case{Path: "/hello", Body: table.Exact("goodbye")}, []string{`^body: "hello", want "goodbye"$`}},
+	})
+}
+
+func TestPanic(t *testing.T) {
+	rec := new(recordT)
+	elems := Table(testHandler(), []Case{{Path: "/panic"}})
+	func() {
+		defer func() {
+			if e := recover(); e != "handler panic" {
+				t.Errorf("unexpected panic %v", e)
+			}
+		}()
+		elems[0].Test(rec)
+	}()
+	if s := fmt.Sprint(elems[0]); s != "GET /panic" {
+		t.Errorf("unexpected name %q", s)
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+/*  Filename:    tabletest.go
+ *  Description: A recording table.T for testing test code.
+ */
+
+/*
+Package tabletest tests table elements and assertion helpers. Run calls a test
+function with a T which records messages, failures and control flow instead of
+reporting them, and Expect checks the recording.
+
+	rec := tabletest.Run(func(t table.T) { myElem{input: "bad"}.Test(t) })
+	tabletest.Expect(t, rec, tabletest.Fails(), tabletest.Logs("error", `^parse error`))
+*/
+package tabletest
+
+import (
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/bmatsuo/go-table/table"
+)
+
+// A message recorded by a T. Kind is "log", "error", "fatal" or "skip".
+type Entry struct {
+	Kind string
+	Text string
+}
+
+func (e Entry) String() string { return e.Kind + ": " + e.Text }
+
+// A T records messages and failures. FailNow, Fatal, Fatalf, SkipNow, Skip
+// and Skipf stop the goroutine calling them, so (as with *testing.T) they must
+// only be called from the goroutine started by Run. A T is safe for
+// concurrent use.
+type T struct {
+	mu      sync.Mutex
+	failed  bool
+	stopped bool
+	skipped bool
+	entries []Entry
+	clean   []func()
+}
+
+var _ table.T = new(T)
+
+// Call test with a new T in a new goroutine, and return the T after test
+// returns or is stopped. Functions registered with Cleanup run before Run
+// returns. A panic in test is raised again by Run.
+func Run(test func(t table.T)) *T {
+	t := new(T)
+	done := make(chan interface{})
+	go func() {
+		defer func() {
+			e := recover()
+			t.runCleanups()
+			done <- e
+		}()
+		test(t)
+	}()
+	if e := <-done; e != nil {
+		panic(e)
+	}
+	return t
+}
+
+func (t *T) record(kind, text string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.entries = append(t.entries, Entry{kind, text})
+	t.failed = t.failed || kind == "error" || kind == "fatal"
+}
+
+func (t *T) stop() {
+	t.mu.Lock()
+	t.stopped = true
+	t.mu.Unlock()
+	runtime.Goexit()
+}
+
+func (t *T) Log(args ...interface{})                 { t.record("log", fmt.Sprint(args...)) }
+func (t *T) Logf(format string, args ...interface{}) { t.record("log", fmt.Sprintf(format, args...)) }
+func (t *T) Error(args ...interface{})               { t.record("error", fmt.Sprint(args...)) }
+func (t *T) Errorf(format string, args ...interface{}) {
+	t.record("error", fmt.Sprintf(format, args...))
+}
+func (t *T) Fatal(args ...interface{}) { t.record("fatal", fmt.Sprint(args...)); t.stop() }
+func (t *T) Fatalf(format string, args ...interface{}) {
+	t.record("fatal", fmt.Sprintf(format, args...))
+	t.stop()
+}
+func (t *T) Skip(args ...interface{}) { t.record("skip", fmt.Sprint(args...)); t.SkipNow() }
+func (t *T) Skipf(format string, args ...interface{}) {
+	t.record("skip", fmt.Sprintf(format, args...))
+	t.SkipNow()
+}
+
+func (t *T) Fail() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.failed = true
+}
+func (t *T) FailNow() { t.Fail(); t.stop() }
+func (t *T) SkipNow() {
+	t.mu.Lock()
+	t.skipped = true
+	t.mu.Unlock()
+	t.stop()
+}
+
+// Helper does nothing; it lets T stand in for *testing.T.
+func (t *T) Helper() {}
+
+// Register fn to be called when the test function passed to Run finishes.
+// Functions are called in the reverse of the order they are registered.
+func (t *T) Cleanup(fn func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clean = append(t.clean, fn)
+}
+
+func (t *T) runCleanups() {
+	for {
+		t.mu.Lock()
+		n := len(t.clean)
+		if n == 0 {
+			t.mu.Unlock()
+			return
+		}
+		fn := t.clean[n-1]
+		t.clean = t.clean[:n-1]
+		t.mu.Unlock()
+		fn()
+	}
+}
+
+// Reports whether the test failed.
+func (t *T) Failed() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.failed
+}
+
+// Reports whether the test was stopped by FailNow, SkipNow or a function
+// calling them.
+func (t *T) Stopped() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stopped
+}
+
+// Reports whether the test was skipped.
+func (t *T) Skipped() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.skipped
+}
+
+// The recorded messages, in the order they were recorded.
+func (t *T) Entries() []Entry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]Entry(nil), t.entries...)
+}
+
+// The text of recorded messages of the given kind, or of all messages when
+// kind is empty.
+func (t *T) Messages(kind string) (texts []string) {
+	for _, e := range t.Entries() {
+		if kind == "" || e.Kind == kind {
+			texts = append(texts, e.Text)
+		}
+	}
+	return
+}
+
+// The recorded messages, one per line.
+func (t *T) String() string {
+	var lines []string
+	for _, e := range t.Entries() {
+		lines = append(lines, e.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// A Check inspects a recording, returning an error describing how it differs
+// from what was expected.
+type Check func(rec *T) error
+
+// Check that the test failed.
+func Fails() Check {
+	return func(rec *T) error {
+		if !rec.Failed() {
+			return fmt.Errorf("passed; expected failure")
+		}
+		return nil
+	}
+}
+
+// Check that the test didn't fail.
+func Passes() Check {
+	return func(rec *T) error {
+		if rec.Failed() {
+			return fmt.Errorf("failed; expected success")
+		}
+		return nil
+	}
+}
+
+// Check that the test was stopped by FailNow or SkipNow.
+func Stops() Check {
+	return func(rec *T) error {
+		if !rec.Stopped() {
+			return fmt.Errorf("ran to completion; expected to be stopped")
+		}
+		return nil
+	}
+}
+
+// Check that the test was skipped.
+func Skips() Check {
+	return func(rec *T) error {
+		if !rec.Skipped() {
+			return fmt.Errorf("not skipped")
+		}
+		return nil
+	}
+}
+
+// Check that a message of the given kind (any kind, if empty) matches the
+// regular expression pattern.
+func Logs(kind, pattern string) Check {
+	r := regexp.MustCompile(pattern)
+	return func(rec *T) error {
+		for _, m := range rec.Messages(kind) {
+			if r.MatchString(m) {
+				return nil
+			}
+		}
+		return fmt.Errorf("no %s message matching %q", kindName(kind), pattern)
+	}
+}
+
+// Check that no message of the given kind (any kind, if empty) matches the
+// regular expression pattern.
+func NoLogs(kind, pattern string) Check {
+	r := regexp.MustCompile(pattern)
+	return func(rec *T) error {
+		for _, m := range rec.Messages(kind) {
+			if r.MatchString(m) {
+				return fmt.Errorf("unexpected %s message %q", kindName(kind), m)
+			}
+		}
+		return nil
+	}
+}
+
+// Check the number of messages of the given kind (any kind, if empty).
+func Count(kind string, n int) Check {
+	return func(rec *T) error {
+		if m := len(rec.Messages(kind)); m != n {
+			return fmt.Errorf("%d %s messages; expected %d", m, kindName(kind), n)
+		}
+		return nil
+	}
+}
+
+func kindName(kind string) string {
+	if kind == "" {
+		return "logged"
+	}
+	return kind
+}
+
+// Report each failed check as an error on t, along with the recorded
+// messages. Returns true if all checks passed.
+func Expect(t table.T, rec *T, checks ...Check) bool {
+	ok := true
+	for _, check := range checks {
+		if err := check(rec); err != nil {
+			t.Error(err)
+			ok = false
+		}
+	}
+	if !ok {
+		t.Logf("recorded messages:\n%v", rec)
+	}
+	return ok
+}
@@ -0,0 +1,112 @@
+package tabletest
+
+/*  Filename:    tabletest_test.go
+ *  Description: For testing tabletest.go
+ */
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bmatsuo/go-table/table"
+)
+
+type runTest struct {
+	name    string
+	test    func(t *T)
+	entries []Entry
+	checks  []Check
+}
+
+func (test runTest) Test(t table.T) {
+	after := false
+	rec := Run(func(t table.T) {
+		test.test(t.(*T))
+		after = true
+	})
+	if !reflect.DeepEqual(rec.Entries(), test.entries) {
+		t.Errorf("entries %v != %v", rec.Entries(), test.entries)
+	}
+	if after == rec.Stopped() {
+		t.Errorf("stopped %v, but test returned %v", rec.Stopped(), after)
+	}
+	Expect(t, rec, test.checks...)
+}
+
+func TestRun(t *testing.T) {
+	table.Test(t, []runTest{
+		{"pass", func(t *T) { t.Logf("x=%d", 1) },
+			[]Entry{{"log", "x=1"}},
+			[]Check{Passes(), Logs("log", `^x=1$`), Count("", 1), NoLogs("error", ".")}},
+		{"error", func(t *T) { t.Error("bad"); t.Log("more") },
+			[]Entry{{"error", "bad"}, {"log", "more"}},
+			[]Check{Fails(), Logs("error", "bad"), Count("error", 1)}},
+		{"fail", func(t *T) { t.Fail() },
+			nil,
+			[]Check{Fails(), Count("", 0)}},
+		{"fatal", func(t *T) { t.Fatalf("stop %d", 1); t.Log("unreachable") },
+			[]Entry{{"fatal", "stop 1"}},
+			[]Check{Fails(), Stops(), Logs("", "stop"), NoLogs("", "unreachable")}},
+		{"failnow", func(t *T) { t.FailNow() },
+			nil,
+			[]Check{Fails(), Stops()}},
+		{"skip", func(t *T) { t.Skip("later") },
+			[]Entry{{"skip", "later"}},
+			[]Check{Passes(), Stops(), Skips()}},
+		{"cleanup", func(t *T) {
+			t.Cleanup(func() { t.Log("second") })
+			t.Cleanup(func() { t.Log("first") })
+			t.FailNow()
+		},
+			[]Entry{{"log", "first"}, {"log", "second"}},
+			[]Check{Stops()}},
+	})
+}
+
+type checkTest struct {
+	name   string
+	test   func(t table.T)
+	check  Check
+	errors []string
+}
+
+func (test checkTest) Test(t table.T) {
+	rec := Run(test.test)
+	inner := Run(func(t table.T) { Expect(t, rec, test.check) })
+	if !reflect.DeepEqual(inner.Messages("error"), test.errors) {
+		t.Errorf("errors %q != %q", inner.Messages("error"), test.errors)
+	}
+	if len(test.errors) > 0 {
+		Expect(t, inner, Logs("log", "^recorded messages:\n"))
+	}
+}
+
+func TestChecks(t *testing.T) {
+	pass := func(t table.T) { t.Log("hello") }
+	fail := func(t table.T) { t.Error("oops") }
+	table.Test(t, []checkTest{
+		{"fails", pass, Fails(), []string{"passed; expected failure"}},
+		{"passes", fail, Passes(), []string{"failed; expected success"}},
+		{"stops", pass, Stops(), []string{"ran to completion; expected to be stopped"}},
+		{"skips", pass, Skips(), []string{"not skipped"}},
+		{"logs", pass, Logs("error", "hello"), []string{`no error message matching "hello"`}},
+		{"logs any", fail, Logs("", "hello"), []string{`no logged message matching "hello"`}},
+		{"nologs", pass, NoLogs("", "ell"), []string{`unexpected logged message "hello"`}},
+		{"count", fail, Count("error", 2), []string{"1 error messages; expected 2"}},
+		{"count ok", fail, Count("log", 0), nil},
+	})
+}
+
+func TestElement(t *testing.T) {
+	rec := Run(func(t table.T) { table.Cleanup(t, func() { t.Log("cleaned up") }) })
+	Expect(t, rec, Passes(), Logs("log", "^cleaned up$"))
+}
+
+func TestPanic(t *testing.T) {
+	defer func() {
+		if e := recover(); e != "boom" {
+			t.Errorf("unexpected panic %v", e)
+		}
+	}()
+	Run(func(t table.T) { panic("boom") })
+}
@@ -7,8 +7,10 @@ package table
  */
 
 import (
+	"context"
 	"regexp"
 	"testing"
+	"time"
 )
 
 // Test the internal tTest function.
@@ -125,3 +127,146 @@ func TestTTestExtraTests(t *testing.T) {
 		}
 	}
 }
+
+type xfailElem struct {
+	reason string
+	fn     func(T)
+}
+
+func (test xfailElem) Test(t T)      { test.fn(t) }
+func (test xfailElem) XFail() string { return test.reason }
+
+type xfailTest struct {
+	elem   xfailElem
+	failed bool
+	log    []string
+}
+
+func (test xfailTest) Test(t T) {
+	var result Result
+	ft := fauxTest("xfail", func(t T) { result = elementTest(t, test.elem) })
+	if ft.failed != test.failed || result.Failed != test.failed {
+		t.Errorf("unexpected outcome %v (result %v): %v", ft.failed, result.Failed, ft.log)
+	}
+	if result.XFail != test.elem.reason {
+		t.Errorf("unexpected result xfail %q", result.XFail)
+	}
+	if ft.Len() != len(test.log) {
+		t.Errorf("unexpected log %v", ft.log)
+		return
+	}
+	for i, patt := range test.log {
+		if !ft.logLineLike(i, patt) {
+			t.Errorf("log line %d doesn't match %q: %v", i, patt, ft.log)
+		}
+	}
+}
+
+var xfailTests = []xfailTest{
+	{xfailElem{"", func(t T) { t.Error("bug") }}, true, []string{`^xfail: bug$`}},
+	{xfailElem{"issue 12", func(t T) { t.Error("bug") }}, false,
+		[]string{`^xfail: bug$`, `^xfail: expected failure \(issue 12\)$`}},
+	{xfailElem{"issue 12", func(t T) { t.Fatal("bug"); t.Error("unreachable") }}, false,
+		[]string{`^xfail: bug$`, `expected failure`}},
+	{xfailElem{"issue 12", func(t T) { panic("bug") }}, false,
+		[]string{`^xfail: panic: bug\n`, `expected failure`}},
+	{xfailElem{"issue 12", func(t T) { t.Log("fixed") }}, true,
+		[]string{`^xfail: fixed$`, `^xfail: unexpected pass; expected failure \(issue 12\)$`}},
+}
+
+func TestXFail(t *testing.T) {
+	for i, test := range xfailTests {
+		elementTest(subT(sprintf("xfail %d", i), t), test)
+	}
+}
+
+func TestXFailTimeout(t *testing.T) {
+	done := make(chan struct{})
+	hang := xfailElem{"issue 12", func(t T) {
+		defer close(done)
+		<-Context(t).Done()
+	}}
+	rep := new(recordReporter)
+	ft := fauxTest("xfail", func(t T) {
+		testWith(t, []xfailElem{hang}, Timeout(10*time.Millisecond), Report(rep))
+	})
+	<-done
+	if ft.failed || ft.Len() != 2 || !ft.logLineLike(0, `timed out after 10ms$`) ||
+		!ft.logLineLike(1, `expected failure \(issue 12\)$`) {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+	if len(rep.results) != 1 || rep.results[0].Failed || rep.summary.Failed != 0 {
+		t.Errorf("unexpected results %#v", rep.results)
+	}
+}
+
+// Records the progress of an element stopped by FailNow.
+type fatalElem struct {
+	steps *[]string
+	fn    func(T)
+}
+
+func (test fatalElem) step(s string) func() {
+	return func() { *test.steps = append(*test.steps, s) }
+}
+func (test fatalElem) Test(t T) {
+	Cleanup(t, test.step("cleanup"))
+	test.fn(t)
+	test.step("unreachable")()
+}
+func (test fatalElem) After(t T) { test.step("after")() }
+
+func TestFatalElement(t *testing.T) {
+	for i, fn := range []func(T){
+		func(t T) { t.Fatal("fmsg") },
+		func(t T) { t.Fatalf("%s", "fmsg") },
+		func(t T) { t.Error("fmsg"); t.FailNow() },
+	} {
+		var steps []string
+		rep := new(recordReporter)
+		ft := fauxTest("fatal", func(t T) {
+			testWith(t, []fatalElem{{&steps, fn}, {&steps, func(T) {}}}, Report(rep))
+		})
+		prefix := sprintf("fatal %d:", i)
+		if want := "[after cleanup unreachable after cleanup]"; sprint(steps) != want {
+			t.Errorf("%s steps %v != %v", prefix, steps, want)
+		}
+		if !ft.failed || ft.Len() != 1 || ft.logLike("panic") || !ft.logLineLike(0, "fmsg") {
+			t.Errorf("%s unexpected log %v", prefix, ft.log)
+		}
+		if len(rep.results) != 2 || !rep.results[0].Fatal || rep.results[1].Failed {
+			t.Errorf("%s unexpected results %v", prefix, rep.results)
+		}
+	}
+}
+
+func TestCleanupOutsideElement(t *testing.T) {
+	ft := fauxTest("cleanup", func(t T) { Cleanup(t, func() {}) })
+	if !ft.failed || !ft.logLike("outside of a table element") {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+}
+
+// Waits for its context to be canceled.
+type contextElem chan error
+
+func (test contextElem) Test(t T) {
+	<-Context(t).Done()
+	test <- Context(t).Err()
+}
+
+func TestContext(t *testing.T) {
+	done := make(contextElem, 1)
+	fauxTest("context", func(t T) { testWith(t, []contextElem{done}, Timeout(10*time.Millisecond)) })
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("unexpected context error %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("context not canceled after timeout")
+	}
+	if Context(subT("", t)).Done() != nil {
+		t.Error("context outside of an element can be canceled")
+	}
+}
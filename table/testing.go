@@ -7,54 +7,268 @@ package table
 /*  Filename:    testing.go
  *  Author:      Bryan Matsuo <bmatsuo@soe.ucsc.edu>
  *  Created:     Sat Dec 10 15:09:48 PST 2011
- *  Description: 
+ *  Description:
  */
 
-import ()
+import (
+	"context"
+	"sync"
+	"time"
+)
 
+// A named T. Messages are formatted once, by the configured Formatter, before
+// being passed to the underlying T; sub-T's share the underlying T and join
+// their names to their parent's.
 type testingT struct {
-	name string
-	t    T
+	name  string
+	names []string // The names joined in name, for the legacy layout.
+	loc   string   // Where the element being tested was declared.
+	t     T
+	cfg   *config
+	state *elemState // Nil outside of elementTest.
 }
 
-func subT(name string, t T) *testingT       { return &testingT{name, t} }
-func (t *testingT) dup() (cp *testingT)           { cp = new(testingT); *cp = *t; return }
-func (t *testingT) sub(name string) (s *testingT) { s = subT(name, t); return }
+// The outcome of an element, shared by all T's testing it.
+type elemState struct {
+	sync.Mutex
+	failed bool
+	closed bool   // The element was abandoned (e.g. it timed out).
+	fatal  bool   // The element was stopped by FailNow.
+	held   bool   // Messages are held rather than passed to the underlying T.
+	done   bool   // Held messages were released.
+	xfail  string // Why the element is expected to fail.
+	goid   string // The goroutine testing the element (e.g. "goroutine 7").
+	meta   *Meta
+	log    []string
+	clean  []func()
 
-func (t *testingT) msg(v ...interface{}) (m string) {
-	if m = sprint(v...); t.name != "" {
-		m = msg(t.name, m)
+	observed string // Serialized value observed by an ElementObserve.
+
+	elapsed, before, after time.Duration
+	ctx                    context.Context
+	cancel                 context.CancelFunc
+}
+
+func newElemState() *elemState {
+	s := new(elemState)
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return s
+}
+
+// Record a message (if non-empty) and whether it fails the element. Returns
+// false when the message should not be passed to the underlying T. A nil
+// state records nothing.
+func (s *elemState) record(m string, fail bool) bool {
+	if s == nil {
+		return true
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.closed {
+		return false
+	}
+	if m != "" {
+		s.log = append(s.log, m)
+	}
+	s.failed = s.failed || fail
+	return !s.held
+}
+
+func (s *elemState) setMeta(m Meta) {
+	s.Lock()
+	defer s.Unlock()
+	s.meta = &m
+}
+
+// Hold the messages of an element expected to fail.
+func (s *elemState) expect(reason string) {
+	s.Lock()
+	defer s.Unlock()
+	s.xfail, s.held = reason, true
+}
+
+// Stop holding messages. Returns the held messages and whether the element
+// failed, which it no longer has. Only the first release of an element which
+// is still open returns ok.
+func (s *elemState) release() (log []string, failed, ok bool) {
+	s.Lock()
+	defer s.Unlock()
+	if s.closed || s.done {
+		return
+	}
+	log, failed, ok = s.log, s.failed, true
+	s.failed, s.held, s.done = false, false, true
+	return
+}
+
+// Record that the calling goroutine tests the element.
+func (s *elemState) enter() {
+	s.Lock()
+	defer s.Unlock()
+	s.goid = currentGoroutine()
+}
+
+// Stop the element, unwinding its goroutine. Called from another goroutine
+// (e.g. one started by the element) stop does nothing, as unwinding that
+// goroutine would crash the test binary.
+func (s *elemState) stop() {
+	s.Lock()
+	if s.goid != "" && s.goid != currentGoroutine() {
+		s.Unlock()
+		return
+	}
+	s.fatal = true
+	s.Unlock()
+	panic(errFatal)
+}
+
+// Register a function to run after the element finishes.
+func (s *elemState) cleanup(fn func()) {
+	s.Lock()
+	defer s.Unlock()
+	s.clean = append(s.clean, fn)
+}
+
+// Run registered cleanup functions, most recently registered first.
+func (s *elemState) runCleanups() {
+	for {
+		s.Lock()
+		n := len(s.clean)
+		if n == 0 {
+			s.Unlock()
+			return
+		}
+		fn := s.clean[n-1]
+		s.clean = s.clean[:n-1]
+		s.Unlock()
+		fn()
+	}
+}
+
+// Drop all further messages and cancel the element's context.
+func (s *elemState) close() {
+	s.Lock()
+	s.closed = true
+	s.Unlock()
+	s.cancel()
+}
+
+func (s *elemState) result(name string) Result {
+	s.Lock()
+	defer s.Unlock()
+	return Result{
+		Name:   name,
+		Failed: s.failed,
+		Fatal:  s.fatal,
+		XFail:  s.xfail,
+		Meta:   s.meta,
+		Log:    append([]string(nil), s.log...),
+
+		Elapsed: s.elapsed,
+		Before:  s.before,
+		After:   s.after,
+
+		Observed: s.observed,
+	}
+}
+
+func subT(name string, t T) *testingT {
+	if parent, ok := t.(*testingT); ok {
+		return parent.sub(name)
+	}
+	return &testingT{name: name, names: []string{name}, t: t}
+}
+func (t *testingT) dup() (cp *testingT) { cp = new(testingT); *cp = *t; return }
+func (t *testingT) sub(name string) (s *testingT) {
+	s = t.dup()
+	s.name = joinName(t.name, name)
+	if name != "" {
+		s.names = append(t.names[:len(t.names):len(t.names)], name)
 	}
 	return
 }
+
+// Format a message of the given kind. Without a Format option the legacy
+// layout is used, in which only the messages of Error and Fatal are labeled.
+func (t *testingT) format(kind, text string, labeled bool) string {
+	if t.cfg == nil || t.cfg.format == nil {
+		return located(t.loc, legacyFormat(t.names, kind, text, labeled))
+	}
+	return t.cfg.format.Format(Message{Name: t.name, Location: t.loc, Kind: kind, Text: text})
+}
+
+func (t *testingT) msg(v ...interface{}) string { return t.format("log", sprint(v...), false) }
 func (t *testingT) errmsg(typ string, v ...interface{}) string {
-	name, m := t.name, sprint(v...)
-	switch {
-	case Verbose && name != "":
-		typ = sprintf("%s %s", t.name, typ)
-		fallthrough
-	case Verbose:
-		name = msg(name, typ)
-		fallthrough
-	case name != "":
-		m = msg(name, m)
-	}
-	return m
+	return t.format(typ, sprint(v...), true)
 }
 func (t *testingT) msgf(f string, v ...interface{}) string { return t.msg(sprintf(f, v...)) }
+func (t *testingT) errmsgf(typ, f string, v ...interface{}) string {
+	return t.format(typ, sprintf(f, v...), false)
+}
+
+func (t *testingT) Fail() {
+	if t.state.record("", true) {
+		t.t.Fail()
+	}
+}
 
-func (t *testingT) Fail()                                     { t.t.Fail() }
-func (t *testingT) FailNow()                                  { t.t.FailNow() }
-func (t *testingT) Failed() bool                              { return t.t.Failed() }
-func (t *testingT) log(args ...interface{})                   { t.t.Log(sprint(args...)) }
-func (t *testingT) error(args ...interface{})                 { t.t.Error(sprint(args...)) }
-func (t *testingT) fatal(args ...interface{})                 { t.t.Fatal(sprint(args...)) }
-func (t *testingT) Log(args ...interface{})                   { t.log(t.msg(args...)) }
-func (t *testingT) Error(args ...interface{})                 { t.error(t.errmsg("error", args...)) }
-func (t *testingT) Fatal(args ...interface{})                 { t.fatal(t.errmsg("fatal", args...)) }
-func (t *testingT) Logf(format string, args ...interface{})   { t.log(t.msgf(format, args...)) }
-func (t *testingT) Errorf(format string, args ...interface{}) { t.error(t.msgf(format, args...)) }
-func (t *testingT) Fatalf(format string, args ...interface{}) { t.fatal(t.msgf(format, args...)) }
+// Within an element, FailNow stops only that element. Its After method and
+// cleanup functions still run, and the table continues with the next element.
+// Called from a goroutine started by the element, FailNow fails the element
+// and calls the underlying T's FailNow, which for a *testing.T exits that
+// goroutine (see testing.T.FailNow).
+func (t *testingT) FailNow() {
+	if t.state != nil {
+		if t.state.record("", true) {
+			t.t.Fail()
+		}
+		t.state.stop()
+	}
+	t.t.FailNow()
+}
+
+// Within an element, reports whether that element has failed.
+func (t *testingT) Failed() bool {
+	if t.state != nil {
+		return t.state.result("").Failed
+	}
+	return t.t.Failed()
+}
+func (t *testingT) log(args ...interface{}) {
+	if m := sprint(args...); t.state.record(m, false) {
+		t.t.Log(m)
+	}
+}
+func (t *testingT) error(args ...interface{}) {
+	if m := sprint(args...); t.state.record(m, true) {
+		t.t.Error(m)
+	}
+}
+func (t *testingT) fatal(args ...interface{}) {
+	m := sprint(args...)
+	if t.state != nil {
+		if t.state.record(m, true) {
+			t.t.Error(m)
+		}
+		t.state.stop()
+		t.t.FailNow()
+	}
+	t.t.Fatal(m)
+}
+
+// Unwinds an element stopped by FailNow, Fatal or Fatalf.
+var errFatal = error_("element stopped")
+
+func (t *testingT) Log(args ...interface{})                 { t.log(t.msg(args...)) }
+func (t *testingT) Error(args ...interface{})               { t.error(t.errmsg("error", args...)) }
+func (t *testingT) Fatal(args ...interface{})               { t.fatal(t.errmsg("fatal", args...)) }
+func (t *testingT) Logf(format string, args ...interface{}) { t.log(t.msgf(format, args...)) }
+func (t *testingT) Errorf(format string, args ...interface{}) {
+	t.error(t.errmsgf("error", format, args...))
+}
+func (t *testingT) Fatalf(format string, args ...interface{}) {
+	t.fatal(t.errmsgf("fatal", format, args...))
+}
 
 // Think *testing.T
 type T interface {
@@ -8,6 +8,7 @@ package table
 
 import (
 	"regexp"
+	"runtime"
 	"strings"
 	"testing"
 )
@@ -195,3 +196,33 @@ func TestT(t *testing.T) {
 		test.Test(t)
 	}
 }
+
+// A fauxT whose FailNow exits the calling goroutine, like *testing.T.
+type goexitT struct{ *lockedT }
+
+func (t goexitT) FailNow()                                  { t.Fail(); runtime.Goexit() }
+func (t goexitT) Fatal(args ...interface{})                 { t.Error(args...); t.FailNow() }
+func (t goexitT) Fatalf(format string, args ...interface{}) { t.Fatal(sprintf(format, args...)) }
+
+// Calls Fatal from a goroutine it starts.
+type goroutineFatalElem struct{}
+
+func (test goroutineFatalElem) Test(t T) {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		t.Fatal("in goroutine")
+	}()
+	<-done
+	t.Log("element continued")
+}
+
+func TestFatalInGoroutine(t *testing.T) {
+	gt := goexitT{&lockedT{ft: new(fauxT)}}
+	testWith(gt, []goroutineFatalElem{{}})
+	ft := gt.ft
+	want := []string{"table.goroutineFatalElem 0: in goroutine", "table.goroutineFatalElem 0: element continued"}
+	if !ft.failed || sprint(ft.log) != sprint(want) {
+		t.Errorf("unexpected log %v", ft.log)
+	}
+}
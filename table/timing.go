@@ -0,0 +1,78 @@
+// Copyright 2011, Bryan Matsuo. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package table
+
+/*  Filename:    timing.go
+ *  Description: Running times of table elements.
+ */
+
+import (
+	"flag"
+	"sort"
+	"time"
+)
+
+var slow = flag.Duration("table.slow", 0, "log table elements which take longer than this")
+
+// Fail elements which take longer than d, including their Before and After
+// methods. Elements which take longer than the -table.slow flag are only
+// logged.
+func Budget(d time.Duration) Option { return func(c *config) { c.budget = d } }
+
+// Log the n slowest elements when the table finishes.
+func Slowest(n int) Option { return func(c *config) { c.slowest = n } }
+
+// The current time according to the table's clock.
+func (c *config) now() time.Time {
+	if c == nil || c.clock == nil {
+		return time.Now()
+	}
+	return c.clock()
+}
+
+// Call fn, adding its running time to *d.
+func (t *testingT) timed(d *time.Duration, fn func()) {
+	start := t.cfg.now()
+	defer func() {
+		end := t.cfg.now()
+		t.state.Lock()
+		defer t.state.Unlock()
+		*d += end.Sub(start)
+	}()
+	fn()
+}
+
+// Record the running time of an element started at start, and complain if it
+// was too slow.
+func checkElapsed(t *testingT, start time.Time) {
+	d := t.cfg.now().Sub(start)
+	t.state.Lock()
+	t.state.elapsed = d
+	t.state.Unlock()
+	var budget, threshold time.Duration
+	if t.cfg != nil {
+		budget, threshold = t.cfg.budget, t.cfg.slow
+	} else {
+		threshold = *slow
+	}
+	switch {
+	case budget > 0 && d > budget:
+		t.Errorf("took %v; over budget of %v", d, budget)
+	case threshold > 0 && d > threshold:
+		t.Logf("slow: took %v (-table.slow=%v)", d, threshold)
+	}
+}
+
+// Log the n slowest elements of a table.
+func logSlowest(t *testingT, s Summary, n int) {
+	results := append([]Result(nil), s.Results...)
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Elapsed > results[j].Elapsed })
+	if n < len(results) {
+		results = results[:n]
+	}
+	for i, res := range results {
+		t.Logf("slowest %d: %s: %v (before %v, after %v)", i+1, res.Name, res.Elapsed, res.Before, res.After)
+	}
+}
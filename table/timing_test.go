@@ -0,0 +1,109 @@
+package table
+
+/*  Filename:    timing_test.go
+ *  Description: For testing timing.go
+ */
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// A clock advancing by step each time it is read.
+func fakeClock(step time.Duration) Option {
+	var mu sync.Mutex
+	var now time.Time
+	return func(c *config) {
+		c.clock = func() time.Time {
+			mu.Lock()
+			defer mu.Unlock()
+			now = now.Add(step)
+			return now
+		}
+	}
+}
+
+// Spends ms milliseconds of fake time in its Before, Test and After methods.
+type timedElem struct{ before, test, after int }
+
+// Read the clock of the table tested by t ms times.
+func spend(t T, ms int) {
+	for i := 0; i < ms; i++ {
+		t.(*testingT).cfg.now()
+	}
+}
+
+// Spends its value in milliseconds of fake time testing.
+type spendElem int
+
+func (test spendElem) Test(t T) { spend(t, int(test)) }
+
+func (test timedElem) Before(t T) { spend(t, test.before) }
+func (test timedElem) Test(t T)   { spend(t, test.test) }
+func (test timedElem) After(t T)  { spend(t, test.after) }
+
+func TestTiming(t *testing.T) {
+	rep := new(recordReporter)
+	fauxTest("timing", func(t T) {
+		testWith(t, []timedElem{{0, 0, 0}, {2, 0, 3}}, Report(rep), fakeClock(time.Millisecond))
+	})
+	var got []string
+	for _, res := range rep.results {
+		got = append(got, sprint(res.Elapsed, res.Before, res.After))
+	}
+	want := []string{"5ms 1ms 1ms", "10ms 3ms 4ms"}
+	if sprint(got) != sprint(want) {
+		t.Errorf("unexpected timings %v", got)
+	}
+}
+
+func TestSlowest(t *testing.T) {
+	ft := fauxTest("slow", func(t T) {
+		testWith(t, []spendElem{1, 9, 4}, Slowest(2), fakeClock(time.Millisecond))
+	})
+	want := []string{
+		"slow: slowest 1: slow: table.spendElem 1: 10ms (before 0s, after 0s)",
+		"slow: slowest 2: slow: table.spendElem 2: 5ms (before 0s, after 0s)",
+	}
+	if got := sprint(ft.log); got != sprint(want) {
+		t.Errorf("unexpected log %v", got)
+	}
+}
+
+type slowTest struct {
+	flag   time.Duration
+	budget time.Duration
+	log    []string
+	failed bool
+}
+
+func (test slowTest) Test(t T) {
+	defer func(d time.Duration) { *slow = d }(*slow)
+	*slow = test.flag
+	ft := fauxTest("slow", func(t T) {
+		testWith(t, []spendElem{1, 5}, Budget(test.budget), fakeClock(time.Millisecond))
+	})
+	if got := sprint(ft.log); got != sprint(test.log) {
+		t.Errorf("unexpected log %v", got)
+	}
+	if ft.failed != test.failed {
+		t.Errorf("failed %v != %v", ft.failed, test.failed)
+	}
+}
+
+var slowTests = []slowTest{
+	{0, 0, nil, false},
+	{3 * time.Millisecond, 0, []string{"slow: table.spendElem 1: slow: took 6ms (-table.slow=3ms)"}, false},
+	{0, 3 * time.Millisecond, []string{"slow: table.spendElem 1: took 6ms; over budget of 3ms"}, true},
+	{time.Millisecond, 3 * time.Millisecond, []string{
+		"slow: table.spendElem 0: slow: took 2ms (-table.slow=1ms)",
+		"slow: table.spendElem 1: took 6ms; over budget of 3ms",
+	}, true},
+}
+
+func TestSlow(t *testing.T) {
+	for i, test := range slowTests {
+		elementTest(subT(sprintf("slow %d", i), t), test)
+	}
+}